@@ -1,12 +1,333 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/aldyaz/csgo-roster/internal/config"
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
 	internal "github.com/aldyaz/csgo-roster/internal/http"
+	"github.com/aldyaz/csgo-roster/internal/lineup"
+	"github.com/aldyaz/csgo-roster/internal/notif"
+	"github.com/aldyaz/csgo-roster/internal/outbox"
+	"github.com/aldyaz/csgo-roster/internal/permission"
 	"github.com/aldyaz/csgo-roster/internal/roster"
+	"github.com/aldyaz/csgo-roster/internal/subscription"
+	"github.com/aldyaz/csgo-roster/internal/team"
+	"github.com/aldyaz/csgo-roster/internal/transfer"
+	"github.com/aldyaz/csgo-roster/internal/webhook"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
 )
 
 func main() {
-	rosterService := roster.NewService()
-	s := internal.NewServer(rosterService)
-	s.ServeHTTP()
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path of the optional YAML config file")
+	printConfig := flag.Bool("print-config", false, "print the config with the secrets redacted and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("load config %s\n", err)
+	}
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("print config %s\n", err)
+		}
+		return
+	}
+
+	db, err := sqlx.Connect("postgres", string(cfg.Database.URL))
+	if err != nil {
+		log.Fatalf("connect database %s\n", err)
+	}
+
+	manager := data.NewManager(db)
+	rosterStorage := data.NewPostgresStorage(db, "rosters", entity.Roster{})
+	teamStorage := data.NewPostgresStorage(db, "teams", entity.Team{})
+	transferStorage := data.NewPostgresStorage(db, "transfers", entity.Transfer{})
+
+	subscriptionStorage := data.NewPostgresStorage(db, "subscriptions", entity.Subscription{})
+	webhookStorage := data.NewPostgresStorage(db, "webhooks", entity.Webhook{})
+	deliveryStorage := data.NewPostgresStorage(db, "deliveries", entity.Delivery{})
+
+	feedNotifier := newFeedNotifier(cfg.Notifier.Slack)
+	subscriptionService := subscription.NewService(feedNotifier, subscriptionStorage, teamStorage)
+	webhookService := webhook.NewService(webhookStorage, deliveryStorage)
+
+	permissionStorage := data.NewPostgresStorage(db, "permissions", entity.Permission{})
+	permissionService := permission.NewService(permissionStorage, teamStorage)
+
+	// the services save the events to the outbox, they're published to each subscriber once committed.
+	// The subscriber names are saved along with the events, they must not be changed.
+	const subscriptionSubscriber, webhookSubscriber = "subscriptions", "webhooks"
+	outboxStorage := data.NewPostgresStorage(db, "outbox", entity.OutboxEvent{})
+	publisher := outbox.NewOutbox(outboxStorage, subscriptionSubscriber, webhookSubscriber)
+
+	checker := lineup.NewChecker(lineup.Rules{
+		ActivePlayers:    cfg.Lineup.ActivePlayers,
+		MaxPrimaryAWPers: cfg.Lineup.MaxPrimaryAWPers,
+		MinIGLs:          cfg.Lineup.MinIGLs,
+		MaxSubstitutes:   cfg.Lineup.MaxSubstitutes,
+	}, teamStorage, rosterStorage)
+	rosterService := roster.NewService(manager, checker, publisher, rosterStorage, teamStorage)
+	teamService := team.NewService(manager, checker, publisher, teamStorage, rosterStorage, transferStorage, rosterStorage.IncludeDeleted())
+	transferService := transfer.NewService(manager, checker, publisher, transferStorage, rosterStorage, teamStorage)
+
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	dispatchers := &sync.WaitGroup{}
+	subscriptionOutbox := outbox.NewDispatcher(manager, outboxStorage, subscriptionSubscriber, subscriptionService, outbox.DispatcherConfig{})
+	webhookOutbox := outbox.NewDispatcher(manager, outboxStorage, webhookSubscriber, webhookService, outbox.DispatcherConfig{})
+	webhookDispatcher := webhook.NewDispatcher(manager, webhookStorage, deliveryStorage, webhook.DispatcherConfig{})
+	loanExpirer := transfer.NewExpirer(transferService, transfer.ExpirerConfig{})
+	for _, run := range []func(context.Context){subscriptionOutbox.Run, webhookOutbox.Run, webhookDispatcher.Run, loanExpirer.Run} {
+		dispatchers.Add(1)
+		go func(run func(context.Context)) {
+			defer dispatchers.Done()
+			run(dispatchCtx)
+		}(run)
+	}
+
+	notifier := newNotifier(cfg.Notifier)
+	responder := internal.NewResponder(notifier)
+	s := internal.NewServer(internal.ServerConfig{
+		Addr:            cfg.HTTP.Addr,
+		ReadTimeout:     cfg.HTTP.ReadTimeout,
+		WriteTimeout:    cfg.HTTP.WriteTimeout,
+		IdleTimeout:     cfg.HTTP.IdleTimeout,
+		MaxHeaderBytes:  cfg.HTTP.MaxHeaderBytes,
+		ShutdownTimeout: cfg.HTTP.ShutdownTimeout,
+		CORS:            newCORSConfig(cfg.HTTP.CORS),
+		Authenticator:   newAuthenticator(cfg.Auth),
+		AuthDisabled:    cfg.Auth.Disabled,
+	}, responder, rosterService, teamService, transferService, subscriptionService, webhookService, permissionService)
+
+	// the dispatchers publish to the feed notifier and use the db, so they're stopped first
+	s.OnShutdown(func(ctx context.Context) error {
+		stopDispatch()
+		return wait(ctx, dispatchers)
+	})
+	s.OnShutdown(shutdownNotifier(feedNotifier))
+	s.OnShutdown(shutdownNotifier(notifier))
+	s.OnShutdown(func(ctx context.Context) error {
+		return db.Close()
+	})
+
+	if err := s.ServeHTTP(); err != nil {
+		log.Fatalf("listen %s\n", err)
+	}
+}
+
+// newCORSConfig creates the cross-origin policies of the server
+func newCORSConfig(cfg config.CORSConfig) internal.CORSConfig {
+	policy := func(p config.CORSPolicy) internal.CORSPolicy {
+		return internal.CORSPolicy{
+			AllowedOrigins:   p.AllowedOrigins,
+			AllowedMethods:   p.AllowedMethods,
+			AllowedHeaders:   p.AllowedHeaders,
+			ExposedHeaders:   p.ExposedHeaders,
+			MaxAge:           p.MaxAge,
+			AllowCredentials: p.AllowCredentials,
+		}
+	}
+
+	routes := map[string]internal.CORSPolicy{}
+	for prefix, p := range cfg.Routes {
+		routes[prefix] = policy(p)
+	}
+	return internal.CORSConfig{Default: policy(cfg.CORSPolicy), Routes: routes}
+}
+
+// newAuthenticator creates the authenticator of the JWTs then the API keys,
+// there is none if the authentication is disabled
+func newAuthenticator(cfg config.AuthConfig) internal.Authenticator {
+	if cfg.Disabled {
+		log.Println("WARNING: the authentication is disabled, every request is allowed to do anything")
+		return nil
+	}
+
+	authenticators := internal.Authenticators{}
+	keys := []internal.JWTKey{}
+	if cfg.JWT.Secret != "" {
+		keys = append(keys, internal.JWTKey{Algorithm: internal.AlgHS256, Secret: []byte(cfg.JWT.Secret)})
+	}
+	if cfg.JWT.PublicKeyFile != "" {
+		b, err := ioutil.ReadFile(cfg.JWT.PublicKeyFile)
+		if err != nil {
+			log.Fatalf("read JWT public key %s\n", err)
+		}
+		publicKey, err := internal.ParseRSAPublicKey(b)
+		if err != nil {
+			log.Fatalf("parse JWT public key %s\n", err)
+		}
+		keys = append(keys, internal.JWTKey{Algorithm: internal.AlgRS256, PublicKey: publicKey})
+	}
+	if cfg.JWT.JWKSFile != "" {
+		jwks, err := internal.LoadJWKS(cfg.JWT.JWKSFile)
+		if err != nil {
+			log.Fatalf("load JWKS %s\n", err)
+		}
+		keys = append(keys, jwks...)
+	}
+	if len(keys) > 0 {
+		authenticators = append(authenticators, internal.NewJWTAuthenticator(internal.JWTConfig{
+			Keys:        keys,
+			Issuer:      cfg.JWT.Issuer,
+			Audience:    cfg.JWT.Audience,
+			Leeway:      cfg.JWT.Leeway,
+			UserIDClaim: cfg.JWT.UserIDClaim,
+		}))
+	}
+
+	if len(cfg.APIKeys) > 0 {
+		apiKeys := make([]internal.APIKey, len(cfg.APIKeys))
+		for i, k := range cfg.APIKeys {
+			apiKeys[i] = internal.APIKey{Name: k.Name, Key: string(k.Key), UserID: k.UserID}
+		}
+		authenticators = append(authenticators, internal.NewAPIKeyAuthenticator(apiKeys...))
+	}
+	return authenticators
+}
+
+// shutdownNotifier returns the hook sending the notifications queued in the background
+func shutdownNotifier(n notif.Notifier) internal.ShutdownHook {
+	return func(ctx context.Context) error {
+		if an, ok := n.(*notif.AsyncNotifier); ok {
+			return an.Shutdown(ctx)
+		}
+		return nil
+	}
+}
+
+// wait waits for the wait group until the context is done
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newFeedNotifier creates the notifier for the roster changes of the subscribed teams.
+// The changes are posted to slack, in the subscription channel or the configured channel by default,
+// in the background so the requests don't wait for slack.
+func newFeedNotifier(cfg config.SlackConfig) notif.Notifier {
+	if cfg.Token == "" {
+		log.Println("slack token is not set, roster changes will not be notified")
+		return nil
+	}
+
+	slack := notif.NewSlackNotifier(notif.SlackNotifierConfig{
+		Token:   string(cfg.Token),
+		Channel: cfg.Channel,
+		BaseURL: cfg.APIURL,
+	})
+	return notif.NewAsyncNotifier(slack, notif.AsyncNotifierConfig{})
+}
+
+// newNotifier creates the notifier for the server errors sending to all the configured notifiers,
+// each notifier only sends the notifications at or above its level, all by default.
+// The errors are only logged if none of the notifiers is configured.
+//
+// The identical errors within the window are collapsed into one message,
+// and the messages are sent in the background so the failing requests don't wait for them.
+func newNotifier(cfg config.NotifierConfig) notif.Notifier {
+	notifiers := []notif.Notifier{}
+	for _, name := range cfg.Notifiers {
+		var n notif.Notifier
+		var level string
+		switch name {
+		case config.NotifierSlack:
+			n, level = newSlackNotifier(cfg.Slack), cfg.Slack.Level
+		case config.NotifierWebhook:
+			n, level = newWebhookNotifier(cfg.Webhook), cfg.Webhook.Level
+		case config.NotifierEmail:
+			n, level = newEmailNotifier(cfg.Email), cfg.Email.Level
+		}
+		if n == nil {
+			continue
+		}
+
+		// e.g. the email level "warning" sends the warnings by email too
+		if level != "" {
+			l, _ := notif.ParseLevel(level)
+			n = notif.NewLevelNotifier(n, l)
+		}
+		notifiers = append(notifiers, n)
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+
+	var n notif.Notifier = notif.NewMultiNotifier(notifiers...)
+	n = notif.NewDedupNotifier(n, notif.DedupNotifierConfig{Window: cfg.Window, Limit: 20})
+	return notif.NewAsyncNotifier(n, notif.AsyncNotifierConfig{})
+}
+
+func newSlackNotifier(cfg config.SlackConfig) notif.Notifier {
+	if cfg.Token == "" || cfg.Channel == "" {
+		log.Println("slack token or channel is not set, server errors will not be notified to slack")
+		return nil
+	}
+
+	return notif.NewSlackNotifier(notif.SlackNotifierConfig{
+		Token:   string(cfg.Token),
+		Channel: cfg.Channel,
+		BaseURL: cfg.APIURL,
+	})
+}
+
+func newWebhookNotifier(cfg config.WebhookConfig) notif.Notifier {
+	if cfg.URL == "" {
+		log.Println("webhook url is not set, server errors will not be notified to the webhook")
+		return nil
+	}
+
+	headers := map[string]string{}
+	for name, value := range cfg.Headers {
+		headers[name] = string(value)
+	}
+	n, err := notif.NewWebhookNotifier(notif.WebhookNotifierConfig{
+		URL:      cfg.URL,
+		Template: cfg.Template,
+		Secret:   string(cfg.Secret),
+		Headers:  headers,
+	})
+	if err != nil {
+		log.Fatalf("create webhook notifier %s\n", err)
+	}
+	return n
+}
+
+func newEmailNotifier(cfg config.EmailConfig) notif.Notifier {
+	if cfg.Host == "" {
+		log.Println("smtp host is not set, server errors will not be notified by email")
+		return nil
+	}
+
+	n, err := notif.NewEmailNotifier(notif.EmailNotifierConfig{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: string(cfg.Password),
+		From:     cfg.From,
+		To:       cfg.To,
+		Subject:  cfg.Subject,
+		StartTLS: cfg.StartTLS,
+	})
+	if err != nil {
+		log.Fatalf("create email notifier %s\n", err)
+	}
+	return n
 }
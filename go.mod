@@ -1,8 +1,13 @@
 module github.com/aldyaz/csgo-roster
 
+go 1.13
+
 require (
 	github.com/go-chi/chi v4.0.1+incompatible
 	github.com/jmoiron/sqlx v1.2.0
+	github.com/lib/pq v1.0.0
 	github.com/rs/cors v1.6.0
-	google.golang.org/appengine v1.6.1 // indirect
+	gopkg.in/yaml.v2 v2.2.2
 )
+
+require google.golang.org/appengine v1.6.1 // indirect
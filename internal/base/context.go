@@ -1,12 +1,21 @@
 package base
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 type contextKey string
 
 const (
 	// KeyUserID represents the current logged-in UserID
 	KeyUserID contextKey = "UserID"
+	// KeyRequestID represents the id of the current request
+	KeyRequestID contextKey = "RequestID"
+	// KeyClaims represents the claims of the current logged-in user, e.g. the JWT claims
+	KeyClaims contextKey = "Claims"
+	// KeyPermissions represents the permissions of the current logged-in user
+	KeyPermissions contextKey = "Permissions"
 )
 
 // CurrentUser gets current user id from the context
@@ -18,3 +27,53 @@ func CurrentUser(ctx context.Context) *int {
 	}
 	return nil
 }
+
+// CurrentClaims gets the claims of the current user from the context, nil if not logged in
+func CurrentClaims(ctx context.Context) map[string]interface{} {
+	claims, _ := ctx.Value(KeyClaims).(map[string]interface{})
+	return claims
+}
+
+// CurrentPermissions gets the permissions of the current user from the context, nil if not loaded
+func CurrentPermissions(ctx context.Context) *Permissions {
+	permissions, _ := ctx.Value(KeyPermissions).(*Permissions)
+	return permissions
+}
+
+// AuthorizeView returns ForbiddenError if the current user can't read the rosters
+func AuthorizeView(ctx context.Context) error {
+	if !CurrentPermissions(ctx).CanView() {
+		return &ForbiddenError{Message: "not allowed to view the rosters"}
+	}
+	return nil
+}
+
+// AuthorizeManager returns ForbiddenError if the current user doesn't manage any team
+func AuthorizeManager(ctx context.Context) error {
+	if !CurrentPermissions(ctx).CanManageAny() {
+		return &ForbiddenError{Message: "not allowed to manage the rosters"}
+	}
+	return nil
+}
+
+// AuthorizeTeam returns ForbiddenError if the current user can't manage the team
+func AuthorizeTeam(ctx context.Context, teamID int) error {
+	if !CurrentPermissions(ctx).CanManage(teamID) {
+		return &ForbiddenError{Message: fmt.Sprintf("not allowed to manage team %d", teamID)}
+	}
+	return nil
+}
+
+// AuthorizeAdmin returns ForbiddenError if the current user is not an admin
+func AuthorizeAdmin(ctx context.Context) error {
+	if !CurrentPermissions(ctx).IsAdmin() {
+		return &ForbiddenError{Message: "only allowed to the admins"}
+	}
+	return nil
+}
+
+// RequestID gets current request id from the context
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(KeyRequestID).(string)
+	return requestID
+}
@@ -0,0 +1,79 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NotFoundError is returned when the requested resource doesn't exist
+type NotFoundError struct {
+	Resource string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s not found", e.Resource)
+}
+
+// ConflictError is returned when the request conflicts with the current state of the resource
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+// ForbiddenError is returned when the current user is not allowed to do the request
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+// FieldError represents the validation failure of a single field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when the input is invalid, it lists every invalid field
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+// NewValidationError creates a new validation error of a single invalid field
+func NewValidationError(field, message string) *ValidationError {
+	e := &ValidationError{}
+	e.Add(field, message)
+	return e
+}
+
+// Add adds the invalid field, the message should complete the sentence starting with the field name
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, &FieldError{Field: field, Message: message})
+}
+
+// AddCode adds the invalid field along with its machine-readable code
+func (e *ValidationError) AddCode(field, code, message string) {
+	e.Fields = append(e.Fields, &FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err returns nil if there is no invalid field,
+// so the accumulated validation error can be returned directly
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = fmt.Sprintf("%s %s", f.Field, f.Message)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,40 @@
+package base
+
+// Permissions represents the roles granted to the current user.
+// The nil permissions allow nothing, so the user without any role is forbidden.
+type Permissions struct {
+	Admin   bool
+	Viewer  bool
+	TeamIDs []int
+}
+
+// IsAdmin reports whether the user can do everything
+func (p *Permissions) IsAdmin() bool {
+	return p != nil && p.Admin
+}
+
+// CanView reports whether the user can read the rosters, every role can
+func (p *Permissions) CanView() bool {
+	return p != nil && (p.Admin || p.Viewer || len(p.TeamIDs) > 0)
+}
+
+// CanManageAny reports whether the user can edit the roster of at least one team
+func (p *Permissions) CanManageAny() bool {
+	return p != nil && (p.Admin || len(p.TeamIDs) > 0)
+}
+
+// CanManage reports whether the user can edit the roster of the team
+func (p *Permissions) CanManage(teamID int) bool {
+	if p == nil {
+		return false
+	}
+	if p.Admin {
+		return true
+	}
+	for _, id := range p.TeamIDs {
+		if id == teamID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,373 @@
+// Package config loads the application configuration from an optional YAML file and the environment variables
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The notifier names
+const (
+	NotifierSlack   = "slack"
+	NotifierWebhook = "webhook"
+	NotifierEmail   = "email"
+)
+
+// corsRoutes are the prefixes of the route groups of the http server having their own cross-origin policy
+var corsRoutes = []string{"/", "/v1/rosters", "/v1/teams", "/v1/players", "/v1/webhooks", "/v1/users"}
+
+// levelNames are the notification levels, from the least to the most severe
+var levelNames = []string{"info", "warning", "error", "critical"}
+
+// Config represents the application configuration
+type Config struct {
+	HTTP     HTTPConfig     `yaml:"http"`
+	Database DatabaseConfig `yaml:"database"`
+	Notifier NotifierConfig `yaml:"notifier"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Lineup   LineupConfig   `yaml:"lineup"`
+}
+
+// HTTPConfig represents the http listener configuration
+type HTTPConfig struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"readTimeout"`
+	WriteTimeout    time.Duration `yaml:"writeTimeout"`
+	IdleTimeout     time.Duration `yaml:"idleTimeout"`
+	MaxHeaderBytes  int           `yaml:"maxHeaderBytes"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	CORS            CORSConfig    `yaml:"cors"`
+}
+
+// CORSConfig represents the cross-origin policies,
+// Routes replaces the default policy for the route groups by their prefix, e.g. "/v1/webhooks"
+type CORSConfig struct {
+	CORSPolicy `yaml:",inline"`
+	Routes     map[string]CORSPolicy `yaml:"routes"`
+}
+
+// CORSPolicy represents the cross-origin policy of a group of routes,
+// an origin may contain one wildcard to allow the subdomains, e.g. https://*.example.com
+type CORSPolicy struct {
+	AllowedOrigins   []string      `yaml:"allowedOrigins"`
+	AllowedMethods   []string      `yaml:"allowedMethods"`
+	AllowedHeaders   []string      `yaml:"allowedHeaders"`
+	ExposedHeaders   []string      `yaml:"exposedHeaders"`
+	MaxAge           time.Duration `yaml:"maxAge"`
+	AllowCredentials bool          `yaml:"allowCredentials"`
+}
+
+// DatabaseConfig represents the postgres connection configuration
+type DatabaseConfig struct {
+	URL URL `yaml:"url"`
+}
+
+// NotifierConfig represents the configuration of the server errors and the roster changes notifiers.
+//
+// The server errors are notified to all the configured Notifiers,
+// the identical errors within Window are collapsed into one notification.
+// The roster changes are posted to slack.
+type NotifierConfig struct {
+	Notifiers []string      `yaml:"notifiers"`
+	Window    time.Duration `yaml:"window"`
+	Slack     SlackConfig   `yaml:"slack"`
+	Webhook   WebhookConfig `yaml:"webhook"`
+	Email     EmailConfig   `yaml:"email"`
+}
+
+// SlackConfig represents the slack notifier configuration,
+// Level is the minimum level of the server errors notified to slack
+type SlackConfig struct {
+	Token   Secret `yaml:"token"`
+	Channel string `yaml:"channel"`
+	APIURL  string `yaml:"apiUrl"`
+	Level   string `yaml:"level"`
+}
+
+// WebhookConfig represents the webhook notifier configuration,
+// Level is the minimum level of the server errors notified to the webhook.
+// The header values are secrets since they usually carry the credentials, e.g. Authorization.
+type WebhookConfig struct {
+	URL      string            `yaml:"url"`
+	Secret   Secret            `yaml:"secret"`
+	Template string            `yaml:"template"`
+	Headers  map[string]Secret `yaml:"headers"`
+	Level    string            `yaml:"level"`
+}
+
+// EmailConfig represents the email notifier configuration,
+// Level is the minimum level of the server errors notified by email
+type EmailConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"`
+	Username string   `yaml:"username"`
+	Password Secret   `yaml:"password"`
+	StartTLS bool     `yaml:"startTls"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	Subject  string   `yaml:"subject"`
+	Level    string   `yaml:"level"`
+}
+
+// AuthConfig represents the authentication configuration.
+// The JWT keys or the API keys are required, unless Disabled explicitly serves every request
+// without authentication with all the permissions, e.g. for the local development.
+type AuthConfig struct {
+	Disabled bool           `yaml:"disabled"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	APIKeys  []APIKeyConfig `yaml:"apiKeys"`
+}
+
+// JWTConfig represents the JWT verification configuration.
+// The HS256 tokens are verified with Secret, the RS256 tokens with the PEM public key
+// of PublicKeyFile, and both with the keys of the local JWKSFile.
+type JWTConfig struct {
+	Secret        Secret        `yaml:"secret"`
+	PublicKeyFile string        `yaml:"publicKeyFile"`
+	JWKSFile      string        `yaml:"jwksFile"`
+	Issuer        string        `yaml:"issuer"`
+	Audience      string        `yaml:"audience"`
+	Leeway        time.Duration `yaml:"leeway"`
+	UserIDClaim   string        `yaml:"userIdClaim"`
+}
+
+// APIKeyConfig represents the static API key of a user
+type APIKeyConfig struct {
+	Name   string `yaml:"name"`
+	Key    Secret `yaml:"key"`
+	UserID int    `yaml:"userId"`
+}
+
+// LineupConfig represents the composition rules of the team lineups, a negative value disables the rule
+type LineupConfig struct {
+	ActivePlayers    int `yaml:"activePlayers"`
+	MaxPrimaryAWPers int `yaml:"maxPrimaryAwpers"`
+	MinIGLs          int `yaml:"minIgls"`
+	MaxSubstitutes   int `yaml:"maxSubstitutes"`
+}
+
+// Configured reports whether any JWT key or API key is configured
+func (c AuthConfig) Configured() bool {
+	jwt := c.JWT
+	return jwt.Secret != "" || jwt.PublicKeyFile != "" || jwt.JWKSFile != "" || len(c.APIKeys) > 0
+}
+
+// Default returns the configuration used for the values neither in the file nor in the environment
+func Default() *Config {
+	return &Config{
+		HTTP: HTTPConfig{
+			Addr:            ":8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			CORS: CORSConfig{
+				CORSPolicy: CORSPolicy{
+					AllowedOrigins: []string{"*"},
+					AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
+					AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Access-Token"},
+					ExposedHeaders: []string{"X-Request-ID", "Link"},
+					MaxAge:         5 * time.Minute,
+				},
+			},
+		},
+		Database: DatabaseConfig{
+			URL: "postgres://postgres@localhost:5432/csgo_roster?sslmode=disable",
+		},
+		Notifier: NotifierConfig{
+			Notifiers: []string{NotifierSlack},
+			Window:    time.Minute,
+			Email: EmailConfig{
+				Port:     587,
+				StartTLS: true,
+			},
+		},
+		Lineup: LineupConfig{
+			ActivePlayers:    5,
+			MaxPrimaryAWPers: 1,
+			MinIGLs:          1,
+			MaxSubstitutes:   2,
+		},
+	}
+}
+
+// Load loads the configuration from the defaults, then the YAML file if the path isn't empty,
+// then the environment variables, the latter overriding the former.
+// It returns an error if the configuration is invalid.
+func Load(path string) (*Config, error) {
+	c := Default()
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error when reading config file: %v", err)
+		}
+		if err := yaml.UnmarshalStrict(b, c); err != nil {
+			return nil, fmt.Errorf("error when parsing config file %s: %v", path, err)
+		}
+	}
+
+	if err := c.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks the configuration, it returns all the invalid values at once
+func (c *Config) Validate() error {
+	errs := &Error{}
+
+	if c.HTTP.Addr == "" {
+		errs.add("http.addr is required")
+	}
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"http.readTimeout", c.HTTP.ReadTimeout},
+		{"http.writeTimeout", c.HTTP.WriteTimeout},
+		{"http.idleTimeout", c.HTTP.IdleTimeout},
+		{"http.shutdownTimeout", c.HTTP.ShutdownTimeout},
+		{"notifier.window", c.Notifier.Window},
+		{"auth.jwt.leeway", c.Auth.JWT.Leeway},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			errs.add("%s must not be negative", d.name)
+		}
+	}
+	if c.HTTP.MaxHeaderBytes < 0 {
+		errs.add("http.maxHeaderBytes must not be negative")
+	}
+
+	c.validateCORS("http.cors", c.HTTP.CORS.CORSPolicy, errs)
+	prefixes := make([]string, 0, len(c.HTTP.CORS.Routes))
+	for prefix := range c.HTTP.CORS.Routes {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		if !contains(corsRoutes, prefix) {
+			errs.add("http.cors.routes has unknown prefix %q, must be one of %s", prefix, strings.Join(corsRoutes, ", "))
+		}
+		c.validateCORS(fmt.Sprintf("http.cors.routes[%s]", prefix), c.HTTP.CORS.Routes[prefix], errs)
+	}
+
+	if c.Database.URL == "" {
+		errs.add("database.url is required")
+	} else if _, err := url.Parse(string(c.Database.URL)); err != nil {
+		errs.add("database.url must be a postgres url")
+	}
+
+	for _, n := range c.Notifier.Notifiers {
+		switch n {
+		case NotifierSlack, NotifierWebhook, NotifierEmail:
+		default:
+			errs.add("notifier.notifiers has unknown notifier %q, must be one of slack, webhook, email", n)
+		}
+	}
+	levels := []struct {
+		name  string
+		value string
+	}{
+		{"notifier.slack.level", c.Notifier.Slack.Level},
+		{"notifier.webhook.level", c.Notifier.Webhook.Level},
+		{"notifier.email.level", c.Notifier.Email.Level},
+	}
+	for _, l := range levels {
+		if l.value == "" {
+			continue
+		}
+		if !contains(levelNames, strings.ToLower(strings.TrimSpace(l.value))) {
+			errs.add("%s must be one of info, warning, error, critical", l.name)
+		}
+	}
+	if c.Notifier.Webhook.URL != "" {
+		u, err := url.ParseRequestURI(c.Notifier.Webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs.add("notifier.webhook.url must be a http(s) url")
+		}
+	}
+	if c.Notifier.Email.Port <= 0 || c.Notifier.Email.Port > 65535 {
+		errs.add("notifier.email.port must be between 1 and 65535")
+	}
+	if c.Notifier.Email.Host != "" && (c.Notifier.Email.From == "" || len(c.Notifier.Email.To) == 0) {
+		errs.add("notifier.email.from and notifier.email.to are required when notifier.email.host is set")
+	}
+
+	switch {
+	case c.Auth.Disabled && c.Auth.Configured():
+		errs.add("auth.disabled must not be set along with the JWT keys or the API keys")
+	case !c.Auth.Disabled && !c.Auth.Configured():
+		errs.add("auth.jwt or auth.apiKeys is required, or set auth.disabled to allow every request without authentication")
+	}
+	keys := map[Secret]bool{}
+	for i, k := range c.Auth.APIKeys {
+		if k.Key == "" {
+			errs.add("auth.apiKeys[%d].key is required", i)
+		} else if keys[k.Key] {
+			errs.add("auth.apiKeys[%d].key is duplicated", i)
+		}
+		keys[k.Key] = true
+		if k.UserID <= 0 {
+			errs.add("auth.apiKeys[%d].userId must be a positive user id", i)
+		}
+	}
+
+	if c.Lineup.ActivePlayers == 0 {
+		errs.add("lineup.activePlayers must be positive, or negative to disable the rule")
+	}
+	return errs.err()
+}
+
+// validateCORS checks the cross-origin policy, the credentials are rejected by browsers with any origin
+func (c *Config) validateCORS(name string, policy CORSPolicy, errs *Error) {
+	for _, o := range policy.AllowedOrigins {
+		if strings.Count(o, "*") > 1 {
+			errs.add("%s.allowedOrigins has invalid origin %q, only one wildcard is allowed", name, o)
+		}
+		if o == "*" && policy.AllowCredentials {
+			errs.add("%s.allowCredentials requires explicit allowedOrigins instead of *", name)
+		}
+	}
+	if policy.MaxAge < 0 {
+		errs.add("%s.maxAge must not be negative", name)
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Error is returned when the configuration is invalid
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid config: %s", strings.Join(e.Problems, "; "))
+}
+
+func (e *Error) add(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+func (e *Error) err() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return e
+}
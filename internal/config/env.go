@@ -0,0 +1,192 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadEnv overrides the configuration with the environment variables that are set
+func (c *Config) loadEnv() error {
+	errs := &Error{}
+	env := &envLoader{errs: errs}
+
+	env.string(&c.HTTP.Addr, "HTTP_ADDR")
+	env.duration(&c.HTTP.ReadTimeout, "HTTP_READ_TIMEOUT")
+	env.duration(&c.HTTP.WriteTimeout, "HTTP_WRITE_TIMEOUT")
+	env.duration(&c.HTTP.IdleTimeout, "HTTP_IDLE_TIMEOUT")
+	env.int(&c.HTTP.MaxHeaderBytes, "HTTP_MAX_HEADER_BYTES")
+	env.duration(&c.HTTP.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+
+	env.list(&c.HTTP.CORS.AllowedOrigins, "CORS_ALLOWED_ORIGINS")
+	env.list(&c.HTTP.CORS.AllowedMethods, "CORS_ALLOWED_METHODS")
+	env.list(&c.HTTP.CORS.AllowedHeaders, "CORS_ALLOWED_HEADERS")
+	env.list(&c.HTTP.CORS.ExposedHeaders, "CORS_EXPOSED_HEADERS")
+	env.duration(&c.HTTP.CORS.MaxAge, "CORS_MAX_AGE")
+	env.bool(&c.HTTP.CORS.AllowCredentials, "CORS_ALLOW_CREDENTIALS")
+
+	if v, ok := os.LookupEnv("DATABASE_URL"); ok {
+		c.Database.URL = URL(v)
+	}
+
+	env.list(&c.Notifier.Notifiers, "NOTIFIER")
+	env.duration(&c.Notifier.Window, "NOTIFY_WINDOW")
+
+	env.secret(&c.Notifier.Slack.Token, "SLACK_TOKEN")
+	env.string(&c.Notifier.Slack.Channel, "SLACK_CHANNEL")
+	env.string(&c.Notifier.Slack.APIURL, "SLACK_API_URL")
+	env.string(&c.Notifier.Slack.Level, "SLACK_LEVEL")
+
+	env.string(&c.Notifier.Webhook.URL, "WEBHOOK_URL")
+	env.secret(&c.Notifier.Webhook.Secret, "WEBHOOK_SECRET")
+	env.string(&c.Notifier.Webhook.Template, "WEBHOOK_TEMPLATE")
+	env.headers(&c.Notifier.Webhook.Headers, "WEBHOOK_HEADERS")
+	env.string(&c.Notifier.Webhook.Level, "WEBHOOK_LEVEL")
+
+	env.string(&c.Notifier.Email.Host, "SMTP_HOST")
+	env.int(&c.Notifier.Email.Port, "SMTP_PORT")
+	env.string(&c.Notifier.Email.Username, "SMTP_USERNAME")
+	env.secret(&c.Notifier.Email.Password, "SMTP_PASSWORD")
+	env.bool(&c.Notifier.Email.StartTLS, "SMTP_STARTTLS")
+	env.string(&c.Notifier.Email.From, "EMAIL_FROM")
+	env.list(&c.Notifier.Email.To, "EMAIL_TO")
+	env.string(&c.Notifier.Email.Subject, "EMAIL_SUBJECT")
+	env.string(&c.Notifier.Email.Level, "EMAIL_LEVEL")
+
+	env.bool(&c.Auth.Disabled, "AUTH_DISABLED")
+	env.secret(&c.Auth.JWT.Secret, "JWT_SECRET")
+	env.string(&c.Auth.JWT.PublicKeyFile, "JWT_PUBLIC_KEY_FILE")
+	env.string(&c.Auth.JWT.JWKSFile, "JWT_JWKS_FILE")
+	env.string(&c.Auth.JWT.Issuer, "JWT_ISSUER")
+	env.string(&c.Auth.JWT.Audience, "JWT_AUDIENCE")
+	env.duration(&c.Auth.JWT.Leeway, "JWT_LEEWAY")
+	env.string(&c.Auth.JWT.UserIDClaim, "JWT_USER_ID_CLAIM")
+	env.apiKeys(&c.Auth.APIKeys, "API_KEYS")
+
+	env.int(&c.Lineup.ActivePlayers, "LINEUP_ACTIVE_PLAYERS")
+	env.int(&c.Lineup.MaxPrimaryAWPers, "LINEUP_MAX_PRIMARY_AWPERS")
+	env.int(&c.Lineup.MinIGLs, "LINEUP_MIN_IGLS")
+	env.int(&c.Lineup.MaxSubstitutes, "LINEUP_MAX_SUBSTITUTES")
+
+	return errs.err()
+}
+
+// envLoader sets the values of the environment variables that are set,
+// the values failed to be parsed are added to the errors
+type envLoader struct {
+	errs *Error
+}
+
+func (l *envLoader) string(dest *string, name string) {
+	if v, ok := os.LookupEnv(name); ok {
+		*dest = v
+	}
+}
+
+func (l *envLoader) secret(dest *Secret, name string) {
+	if v, ok := os.LookupEnv(name); ok {
+		*dest = Secret(v)
+	}
+}
+
+func (l *envLoader) int(dest *int, name string) {
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		l.errs.add("%s must be an integer", name)
+		return
+	}
+	*dest = i
+}
+
+func (l *envLoader) bool(dest *bool, name string) {
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.errs.add("%s must be true or false", name)
+		return
+	}
+	*dest = b
+}
+
+func (l *envLoader) duration(dest *time.Duration, name string) {
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		l.errs.add("%s must be a duration, e.g. 30s", name)
+		return
+	}
+	*dest = d
+}
+
+// list sets the comma separated list, ignoring the empty items
+func (l *envLoader) list(dest *[]string, name string) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	list := []string{}
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*dest = list
+}
+
+// headers sets the headers formatted as "Name1=value1,Name2=value2"
+func (l *envLoader) headers(dest *map[string]Secret, name string) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	headers := map[string]Secret{}
+	for _, h := range strings.Split(v, ",") {
+		if strings.TrimSpace(h) == "" {
+			continue
+		}
+		kv := strings.SplitN(h, "=", 2)
+		if len(kv) != 2 {
+			l.errs.add("%s must be formatted as Name1=value1,Name2=value2", name)
+			return
+		}
+		headers[strings.TrimSpace(kv[0])] = Secret(strings.TrimSpace(kv[1]))
+	}
+	*dest = headers
+}
+
+// apiKeys sets the API keys formatted as "name1:userId1:key1,name2:userId2:key2"
+func (l *envLoader) apiKeys(dest *[]APIKeyConfig, name string) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	keys := []APIKeyConfig{}
+	for _, item := range strings.Split(v, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 3)
+		if len(parts) != 3 {
+			l.errs.add("%s must be formatted as name1:userId1:key1,name2:userId2:key2", name)
+			return
+		}
+		userID, err := strconv.Atoi(parts[1])
+		if err != nil {
+			l.errs.add("%s has invalid user id %q", name, parts[1])
+			return
+		}
+		keys = append(keys, APIKeyConfig{Name: parts[0], UserID: userID, Key: Secret(parts[2])})
+	}
+	*dest = keys
+}
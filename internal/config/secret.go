@@ -0,0 +1,72 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const redacted = "[redacted]"
+
+// Secret represents the secret value, it's redacted when printed
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// MarshalYAML implements the yaml.Marshaler interface
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// URL represents the database url that may contain a password, the password is redacted when printed.
+// Both the postgres:// url, with the password in the user info or the query, and the "key=value" connection string are supported.
+type URL string
+
+var (
+	// passwordPattern matches the password of the "key=value" connection string
+	passwordPattern = regexp.MustCompile(`password=('[^']*'|\S*)`)
+	// queryPasswordPattern matches the password parameter of the url query
+	queryPasswordPattern = regexp.MustCompile(`(^|&)password=[^&]*`)
+)
+
+func (u URL) String() string {
+	parsed, err := url.Parse(string(u))
+	if err != nil {
+		return redacted
+	}
+	if parsed.Scheme == "" {
+		return passwordPattern.ReplaceAllString(string(u), "password="+redacted)
+	}
+	if queryPasswordPattern.MatchString(parsed.RawQuery) {
+		parsed.RawQuery = queryPasswordPattern.ReplaceAllString(parsed.RawQuery, "${1}password="+redacted)
+		u = URL(parsed.String())
+	}
+	if _, ok := parsed.User.Password(); !ok {
+		return string(u)
+	}
+	parsed.User = url.User(parsed.User.Username())
+	return strings.Replace(parsed.String(), "@", ":"+redacted+"@", 1)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface
+func (u URL) MarshalYAML() (interface{}, error) {
+	return u.String(), nil
+}
+
+// Print writes the configuration as YAML with the secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,38 @@
+package data
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const cursorPrefix = "id:"
+
+// ErrInvalidCursor is returned when the pagination cursor can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor encodes the id of the last element into an opaque cursor token
+func encodeCursor(id interface{}) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s%v", cursorPrefix, id)))
+}
+
+// decodeCursor decodes the cursor token into the id of the last element
+func decodeCursor(cursor string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	s := string(b)
+	if !strings.HasPrefix(s, cursorPrefix) {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(s, cursorPrefix), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
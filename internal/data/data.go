@@ -35,9 +35,8 @@ func NewManager(db *sqlx.DB) *Manager {
 
 // RunInTransaction runs the f with the transaction queryable inside the context
 func (m *Manager) RunInTransaction(ctx context.Context, f func(tctx context.Context) error) error {
-	tx, err := m.db.Beginx()
+	tx, err := m.db.BeginTxx(ctx, nil)
 	if err != nil {
-		tx.Rollback()
 		return fmt.Errorf("error when creating transction: %v", err)
 	}
 
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateFormat is the layout of the date in the json representation
+const DateFormat = "2006-01-02"
+
+// Date represents a calendar date without the time of day
+type Date struct {
+	time.Time
+}
+
+// NewDate creates a new date of the given time in UTC
+func NewDate(t time.Time) Date {
+	y, m, d := t.UTC().Date()
+	return Date{Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses the date formatted as "2006-01-02"
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateFormat, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("date must be formatted as %s", DateFormat)
+	}
+	return Date{Time: t}, nil
+}
+
+func (d Date) String() string {
+	return d.Format(DateFormat)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%s"`, d.String())), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (d *Date) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+
+	parsed, err := ParseDate(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (d Date) Value() (driver.Value, error) {
+	return d.Time, nil
+}
+
+// Scan implements the sql.Scanner interface
+func (d *Date) Scan(src interface{}) error {
+	t, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into date", src)
+	}
+	*d = NewDate(t)
+	return nil
+}
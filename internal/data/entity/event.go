@@ -0,0 +1,60 @@
+package entity
+
+import "time"
+
+// The roster event types
+const (
+	EventPlayerAdded       = "roster.player_added"
+	EventPlayerTransferred = "roster.player_transferred"
+	EventPlayerBenched     = "roster.player_benched"
+	EventPlayerRetired     = "roster.player_retired"
+	EventStatusChanged     = "roster.status_changed"
+	EventRoleChanged       = "roster.role_changed"
+)
+
+// EventTypes are all the roster event types
+var EventTypes = []string{
+	EventPlayerAdded,
+	EventPlayerTransferred,
+	EventPlayerBenched,
+	EventPlayerRetired,
+	EventStatusChanged,
+	EventRoleChanged,
+}
+
+// IsEventType reports whether the type is one of the roster event types
+func IsEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// RosterEvent represents a change of a team lineup.
+// From and To are the previous and the new status or role of the changed events,
+// Transfer is set for the events caused by a transfer.
+// ID identifies the event, so its receivers can ignore the duplicates.
+type RosterEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	TeamID     *int      `json:"teamId"`
+	Player     *Roster   `json:"player"`
+	Transfer   *Transfer `json:"transfer,omitempty"`
+	From       string    `json:"from,omitempty"`
+	To         string    `json:"to,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// TeamIDs returns the teams affected by the event, the team a transferred player left included
+func (e *RosterEvent) TeamIDs() []int {
+	ids := []int{}
+	if e.TeamID != nil {
+		ids = append(ids, *e.TeamID)
+	}
+	if e.Transfer != nil && e.Transfer.FromTeamID != nil && (e.TeamID == nil || *e.Transfer.FromTeamID != *e.TeamID) {
+		ids = append(ids, *e.Transfer.FromTeamID)
+	}
+	return ids
+}
@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// JSON represents a raw JSON document stored in a JSONB column.
+// It's sent to the database as text, since lib/pq sends []byte as bytea.
+type JSON []byte
+
+// MarshalJSON implements the json.Marshaler interface
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (j *JSON) UnmarshalJSON(b []byte) error {
+	*j = append((*j)[:0], b...)
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// Scan implements the sql.Scanner interface
+func (j *JSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		*j = append(JSON(nil), v...)
+	case string:
+		*j = JSON(v)
+	case nil:
+		*j = nil
+	default:
+		return fmt.Errorf("cannot scan %T into json", src)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package entity
+
+// RuleViolation represents a broken lineup composition rule
+type RuleViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// LineupChange represents the proposed change of a player within its team lineup.
+// The empty fields are left unchanged.
+type LineupChange struct {
+	RosterID       int      `json:"rosterId"`
+	Status         string   `json:"status,omitempty"`
+	Role           Role     `json:"role,omitempty"`
+	SecondaryRoles RoleList `json:"secondaryRoles,omitempty"`
+}
+
+// LineupChangeList represents the proposed changes of a team lineup
+type LineupChangeList struct {
+	Changes []*LineupChange `json:"changes"`
+}
+
+// LineupCheck represents the result of checking a lineup change against the composition rules.
+// Violations are broken by the change, while warnings were already broken before the change.
+type LineupCheck struct {
+	Valid      bool             `json:"valid"`
+	Violations []*RuleViolation `json:"violations"`
+	Warnings   []*RuleViolation `json:"warnings"`
+}
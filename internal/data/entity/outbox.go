@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// OutboxEvent represents the roster event saved in the same transaction as the change it describes.
+// The event is saved once for each subscriber, so each one is retried independently.
+// It's published once by the outbox dispatcher of the subscriber, PublishedAt is set when it's published,
+// FailedAt is set when it's given up.
+type OutboxEvent struct {
+	ID            int        `json:"outboxId" db:"id"`
+	EventID       string     `json:"eventId" db:"eventId"`
+	EventType     string     `json:"eventType" db:"eventType"`
+	Subscriber    string     `json:"subscriber" db:"subscriber"`
+	Payload       JSON       `json:"payload" db:"payload"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" db:"nextAttemptAt"`
+	LastError     string     `json:"lastError" db:"lastError"`
+	PublishedAt   *time.Time `json:"publishedAt" db:"publishedAt"`
+	FailedAt      *time.Time `json:"failedAt" db:"failedAt"`
+	CreatedAt     time.Time  `json:"createdAt" db:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt" db:"updatedAt"`
+}
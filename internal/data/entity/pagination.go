@@ -0,0 +1,29 @@
+package entity
+
+// PageMeta represents the pagination information of a list
+type PageMeta struct {
+	Total      int `json:"total"`
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	TotalPages int `json:"totalPages"`
+}
+
+// NewPageMeta creates the page meta of the given total elements
+func NewPageMeta(total, page, limit int) *PageMeta {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = (total + limit - 1) / limit
+	}
+	return &PageMeta{
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+	}
+}
+
+// CursorMeta represents the keyset pagination information of a list
+type CursorMeta struct {
+	Limit int    `json:"limit"`
+	Next  string `json:"next,omitempty"`
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// The roles of the permissions
+const (
+	PermissionViewer      = "viewer"
+	PermissionTeamManager = "team_manager"
+	PermissionAdmin       = "admin"
+)
+
+type PermissionList struct {
+	Data []*Permission `json:"data"`
+}
+
+// Permission represents the role granted to a user.
+// The team manager can edit the team of TeamID, the viewer can only read, the admin can edit everything.
+type Permission struct {
+	ID        int        `json:"permissionId" db:"id"`
+	UserID    int        `json:"userId" db:"userId"`
+	Role      string     `json:"role" db:"role"`
+	TeamID    *int       `json:"teamId" db:"teamId"`
+	CreatedAt time.Time  `json:"createdAt" db:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updatedAt"`
+	DeletedAt *time.Time `json:"-" db:"deletedAt"`
+}
@@ -0,0 +1,128 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Role represents the role of a player or a staff within the team
+type Role string
+
+// The known roles
+const (
+	RoleIGL        Role = "igl"
+	RoleAWP        Role = "awp"
+	RoleEntry      Role = "entry"
+	RoleSupport    Role = "support"
+	RoleLurker     Role = "lurker"
+	RoleRifler     Role = "rifler"
+	RoleCoach      Role = "coach"
+	RoleAnalyst    Role = "analyst"
+	RoleSubstitute Role = "substitute"
+)
+
+// Roles lists all the known roles
+var Roles = []Role{
+	RoleIGL, RoleAWP, RoleEntry, RoleSupport, RoleLurker,
+	RoleRifler, RoleCoach, RoleAnalyst, RoleSubstitute,
+}
+
+// roleAliases maps the commonly used role names to the known roles
+var roleAliases = map[string]Role{
+	"in-game leader": RoleIGL,
+	"in game leader": RoleIGL,
+	"awper":          RoleAWP,
+	"sniper":         RoleAWP,
+	"entry fragger":  RoleEntry,
+	"entry-fragger":  RoleEntry,
+	"entryfragger":   RoleEntry,
+	"sub":            RoleSubstitute,
+}
+
+// UnknownRoleError is returned when parsing a role that is not known
+type UnknownRoleError struct {
+	Value string
+}
+
+func (e *UnknownRoleError) Error() string {
+	return fmt.Sprintf("unknown role %q, must be one of %s", e.Value, RoleNames())
+}
+
+// RoleNames returns the comma separated names of the known roles
+func RoleNames() string {
+	names := make([]string, len(Roles))
+	for i, r := range Roles {
+		names[i] = string(r)
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseRole parses the role name case insensitively, including its common aliases
+func ParseRole(s string) (Role, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for _, r := range Roles {
+		if name == string(r) {
+			return r, nil
+		}
+	}
+	if r, ok := roleAliases[name]; ok {
+		return r, nil
+	}
+	return "", &UnknownRoleError{Value: s}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (r *Role) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	parsed, err := ParseRole(s)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// RoleList represents the multiple roles of a player
+type RoleList []Role
+
+// Has reports whether the role is in the list
+func (l RoleList) Has(role Role) bool {
+	for _, r := range l {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements the driver.Valuer interface, the roles are stored as a text array
+func (l RoleList) Value() (driver.Value, error) {
+	a := make(pq.StringArray, 0, len(l))
+	for _, r := range l {
+		a = append(a, string(r))
+	}
+	return a.Value()
+}
+
+// Scan implements the sql.Scanner interface
+func (l *RoleList) Scan(src interface{}) error {
+	var a pq.StringArray
+	if err := a.Scan(src); err != nil {
+		return err
+	}
+
+	list := make(RoleList, len(a))
+	for i, s := range a {
+		list[i] = Role(s)
+	}
+	*l = list
+	return nil
+}
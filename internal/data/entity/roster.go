@@ -1,11 +1,29 @@
 package entity
 
+import "time"
+
+// The roster statuses within its team
+const (
+	StatusActive     = "active"
+	StatusSubstitute = "substitute"
+	StatusBenched    = "benched"
+	StatusInactive   = "inactive"
+)
+
 type RosterList struct {
-	Data []*Roster `json:"data"`
+	Data   []*Roster   `json:"data"`
+	Meta   *PageMeta   `json:"meta,omitempty"`
+	Cursor *CursorMeta `json:"cursor,omitempty"`
 }
 
 type Roster struct {
-	ID   int    `json:"rosterId"`
-	Name string `json:"name"`
-	Role string `json:"role"`
+	ID             int        `json:"rosterId" db:"id"`
+	Name           string     `json:"name" db:"name"`
+	Role           Role       `json:"role" db:"role"`
+	SecondaryRoles RoleList   `json:"secondaryRoles" db:"secondaryRoles"`
+	TeamID         *int       `json:"teamId" db:"teamId"`
+	Status         string     `json:"status" db:"status"`
+	CreatedAt      time.Time  `json:"createdAt" db:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt" db:"updatedAt"`
+	DeletedAt      *time.Time `json:"-" db:"deletedAt"`
 }
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type SubscriptionList struct {
+	Data []*Subscription `json:"data"`
+}
+
+// Subscription represents the notification of a team roster events to a channel.
+// All events are notified if Events is empty, the notifier channel is used if Channel is empty.
+type Subscription struct {
+	ID        int            `json:"subscriptionId" db:"id"`
+	TeamID    int            `json:"teamId" db:"teamId"`
+	Channel   string         `json:"channel" db:"channel"`
+	Events    pq.StringArray `json:"events" db:"events"`
+	CreatedAt time.Time      `json:"createdAt" db:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt" db:"updatedAt"`
+	DeletedAt *time.Time     `json:"-" db:"deletedAt"`
+}
+
+// Accepts reports whether the event type is notified to the subscription
+func (s *Subscription) Accepts(eventType string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
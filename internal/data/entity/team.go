@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+type TeamList struct {
+	Data []*Team   `json:"data"`
+	Meta *PageMeta `json:"meta,omitempty"`
+}
+
+type Team struct {
+	ID        int        `json:"teamId" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Tag       string     `json:"tag" db:"tag"`
+	Region    string     `json:"region" db:"region"`
+	LogoURL   string     `json:"logoUrl" db:"logoUrl"`
+	Founded   *Date      `json:"founded" db:"founded"`
+	CreatedAt time.Time  `json:"createdAt" db:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updatedAt"`
+	DeletedAt *time.Time `json:"-" db:"deletedAt"`
+}
+
+// TeamRoster represents the lineup of a team, at the date if given
+type TeamRoster struct {
+	Team        *Team     `json:"team"`
+	At          *Date     `json:"at,omitempty"`
+	Active      []*Roster `json:"active"`
+	Coaches     []*Roster `json:"coaches"`
+	Analysts    []*Roster `json:"analysts"`
+	Substitutes []*Roster `json:"substitutes"`
+	Benched     []*Roster `json:"benched"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// The transfer types
+const (
+	TransferSigning    = "signing"
+	TransferBenching   = "benching"
+	TransferLoan       = "loan"
+	TransferRetirement = "retirement"
+	// TransferLoanReturn is recorded when a loan ends, it can't be created by the users
+	TransferLoanReturn = "loan_return"
+)
+
+type TransferList struct {
+	Data []*Transfer `json:"data"`
+}
+
+// Transfer represents the move of a player between teams at the effective date
+type Transfer struct {
+	ID         int       `json:"transferId" db:"id"`
+	PlayerID   int       `json:"playerId" db:"playerId"`
+	FromTeamID *int      `json:"fromTeamId" db:"fromTeamId"`
+	ToTeamID   *int      `json:"toTeamId" db:"toTeamId"`
+	Type       string    `json:"type" db:"type"`
+	Date       Date      `json:"date" db:"date"`
+	Fee        *int64    `json:"fee" db:"fee"`
+	LoanUntil  *Date     `json:"loanUntil" db:"loanUntil"`
+	CreatedAt  time.Time `json:"createdAt" db:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updatedAt"`
+}
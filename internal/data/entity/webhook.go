@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// The delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type WebhookList struct {
+	Data []*Webhook `json:"data"`
+}
+
+// Webhook represents the subscription of a partner url to the roster events.
+// All events are delivered if Events is empty.
+// Secret signs the deliveries, it's only shown when the webhook is created.
+type Webhook struct {
+	ID        int            `json:"webhookId" db:"id"`
+	URL       string         `json:"url" db:"url"`
+	Secret    string         `json:"secret,omitempty" db:"secret"`
+	Events    pq.StringArray `json:"events" db:"events"`
+	CreatedAt time.Time      `json:"createdAt" db:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt" db:"updatedAt"`
+	DeletedAt *time.Time     `json:"-" db:"deletedAt"`
+}
+
+// Accepts reports whether the event type is delivered to the webhook
+func (w *Webhook) Accepts(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryList struct {
+	Data []*Delivery `json:"data"`
+}
+
+// Delivery represents the delivery of an event to a webhook and its latest attempt.
+// The pending delivery is attempted at NextAttemptAt.
+type Delivery struct {
+	ID             int        `json:"deliveryId" db:"id"`
+	WebhookID      int        `json:"webhookId" db:"webhookId"`
+	EventID        string     `json:"eventId" db:"eventId"`
+	EventType      string     `json:"eventType" db:"eventType"`
+	Payload        JSON       `json:"payload" db:"payload"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt" db:"nextAttemptAt"`
+	LastAttemptAt  *time.Time `json:"lastAttemptAt" db:"lastAttemptAt"`
+	ResponseStatus *int       `json:"responseStatus" db:"responseStatus"`
+	LastError      string     `json:"lastError" db:"lastError"`
+	CreatedAt      time.Time  `json:"createdAt" db:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt" db:"updatedAt"`
+}
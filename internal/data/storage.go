@@ -9,6 +9,7 @@ type GenericStorage interface {
 	Where(ctx context.Context, elems interface{}, where string, arg interface{}) error
 	FindByID(ctx context.Context, elem interface{}, id interface{}) error
 	FindAll(ctx context.Context, elems interface{}, page int, limit int) error
+	FindAfter(ctx context.Context, elems interface{}, cursor string, limit int) (string, error)
 	Count(ctx context.Context) (int, error)
 	Insert(ctx context.Context, elem interface{}) error
 	InsertBulk(ctx context.Context, elem interface{}) error
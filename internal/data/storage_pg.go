@@ -2,6 +2,7 @@ package data
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
@@ -18,6 +19,7 @@ import (
 type PostgresStorage struct {
 	db              Queryer
 	tableName       string
+	source          string
 	elemType        reflect.Type
 	selectFields    string
 	insertFields    string
@@ -31,6 +33,7 @@ func NewPostgresStorage(db *sqlx.DB, tableName string, elem interface{}) *Postgr
 	return &PostgresStorage{
 		db:              db,
 		tableName:       tableName,
+		source:          source(tableName, elemType),
 		elemType:        elemType,
 		selectFields:    selectFields(elemType),
 		insertFields:    insertFields(elemType),
@@ -39,12 +42,38 @@ func NewPostgresStorage(db *sqlx.DB, tableName string, elem interface{}) *Postgr
 	}
 }
 
+// IncludeDeleted returns a copy of the storage whose select queries read the soft deleted rows too,
+// e.g. to reconstruct the history of the deleted elements
+func (r *PostgresStorage) IncludeDeleted() *PostgresStorage {
+	c := *r
+	c.source = fmt.Sprintf(`"%s"`, r.tableName)
+	return &c
+}
+
 // txFromContext returns the trasanction object from the context
 func txFromContext(ctx context.Context) (Queryer, bool) {
 	q, ok := ctx.Value(txKey).(Queryer)
 	return q, ok
 }
 
+// source returns the relation the select queries read from.
+// If the element has the "deletedAt" field, the soft deleted rows are excluded.
+func source(tableName string, elemType reflect.Type) string {
+	if hasTag(elemType, "deletedAt") {
+		return fmt.Sprintf(`(SELECT * FROM "%s" WHERE "deletedAt" IS NULL) AS "%s"`, tableName, tableName)
+	}
+	return fmt.Sprintf(`"%s"`, tableName)
+}
+
+func hasTag(elemType reflect.Type, dbTag string) bool {
+	for i := 0; i < elemType.NumField(); i++ {
+		if elemType.Field(i).Tag.Get("db") == dbTag {
+			return true
+		}
+	}
+	return false
+}
+
 func selectFields(elemType reflect.Type) string {
 	dbFields := []string{}
 	for i := 0; i < elemType.NumField(); i++ {
@@ -108,7 +137,7 @@ func emptyTag(dbTag string) bool {
 }
 
 func readOnlyTag(dbTag string) bool {
-	readOnlyTags := []string{"id", "createdAt", "updatedAt"}
+	readOnlyTags := []string{"id", "createdAt", "updatedAt", "deletedAt"}
 	for _, t := range readOnlyTags {
 		if dbTag == t {
 			return true
@@ -127,8 +156,8 @@ func (r *PostgresStorage) Single(ctx context.Context, elem interface{}, where st
 		forUpdate = " FOR UPDATE"
 	}
 
-	statement, err := db.PrepareNamed(fmt.Sprintf(`SELECT %s FROM "%s" WHERE %s%s`,
-		r.selectFields, r.tableName, where, forUpdate))
+	statement, err := db.PrepareNamed(fmt.Sprintf(`SELECT %s FROM %s WHERE %s%s`,
+		r.selectFields, r.source, where, forUpdate))
 	if err != nil {
 		return err
 	}
@@ -151,8 +180,8 @@ func (r *PostgresStorage) Where(ctx context.Context, dest interface{}, where str
 		forUpdate = " FOR UPDATE"
 	}
 
-	statement, err := db.PrepareNamed(fmt.Sprintf(`SELECT %s FROM "%s" WHERE %s%s`,
-		r.selectFields, r.tableName, where, forUpdate))
+	statement, err := db.PrepareNamed(fmt.Sprintf(`SELECT %s FROM %s WHERE %s%s`,
+		r.selectFields, r.source, where, forUpdate))
 	if err != nil {
 		return err
 	}
@@ -193,6 +222,38 @@ func (r *PostgresStorage) FindAll(ctx context.Context, dest interface{}, page in
 	return nil
 }
 
+// FindAfter finds the elements after the cursor using the keyset pagination ordered by "id" DESC.
+// The cursor is the opaque token returned by the previous call, pass an empty cursor for the first page.
+// It returns the cursor of the next page, or an empty string when there are no more elements.
+func (r *PostgresStorage) FindAfter(ctx context.Context, dest interface{}, cursor string, limit int) (string, error) {
+	where := `true ORDER BY "id" DESC LIMIT :limit`
+	args := map[string]interface{}{
+		"limit": limit + 1,
+	}
+	if cursor != "" {
+		id, err := decodeCursor(cursor)
+		if err != nil {
+			return "", err
+		}
+		where = `"id" < :cursor ORDER BY "id" DESC LIMIT :limit`
+		args["cursor"] = id
+	}
+
+	err := r.Where(ctx, dest, where, args)
+	if err != nil {
+		return "", err
+	}
+
+	// one extra element is fetched to know whether the next page exists
+	s := reflect.Indirect(reflect.ValueOf(dest))
+	if s.Len() <= limit {
+		return "", nil
+	}
+	s.Set(s.Slice(0, limit))
+
+	return encodeCursor(r.idValue(s.Index(limit - 1))), nil
+}
+
 // Count counts the size of elems inside database
 func (r *PostgresStorage) Count(ctx context.Context) (int, error) {
 	db := r.db
@@ -201,7 +262,7 @@ func (r *PostgresStorage) Count(ctx context.Context) (int, error) {
 		db = tx
 	}
 
-	stmt, err := db.PrepareNamed(fmt.Sprintf("SELECT COUNT(*) FROM %s", r.tableName))
+	stmt, err := db.PrepareNamed(fmt.Sprintf("SELECT COUNT(*) FROM %s", r.source))
 	if err != nil {
 		return 0, err
 	}
@@ -366,7 +427,12 @@ func (r *PostgresStorage) Update(ctx context.Context, elem interface{}) error {
 
 // it assumes the id column named "id"
 func (r *PostgresStorage) findID(elem interface{}) interface{} {
-	v := reflect.ValueOf(elem).Elem()
+	return r.idValue(reflect.ValueOf(elem))
+}
+
+// idValue returns the id of the element value, it can be a struct or a pointer to struct
+func (r *PostgresStorage) idValue(elem reflect.Value) interface{} {
+	v := reflect.Indirect(elem)
 	for i := 0; i < v.NumField(); i++ {
 		dbTag := r.elemType.Field(i).Tag.Get("db")
 		if idTag(dbTag) {
@@ -395,6 +461,7 @@ func (r *PostgresStorage) updateArgs(existingElem interface{}, elem interface{})
 // Delete deletes the elem from database.
 // Delete not really deletes the elem from the db, but it will set the
 // "deletedAt" column to current time.
+// It returns sql.ErrNoRows if there is no elem with the id or it's already deleted.
 func (r *PostgresStorage) Delete(ctx context.Context, id interface{}) error {
 	db := r.db
 	tx, ok := txFromContext(ctx)
@@ -403,7 +470,7 @@ func (r *PostgresStorage) Delete(ctx context.Context, id interface{}) error {
 	}
 
 	statement, err := db.PrepareNamed(fmt.Sprintf(`
-		UPDATE "%s" SET "deletedAt" = :deletedAt WHERE "id" = :id RETURNING %s
+		UPDATE "%s" SET "deletedAt" = :deletedAt WHERE "id" = :id AND "deletedAt" IS NULL RETURNING %s
 	`, r.tableName, r.selectFields))
 	if err != nil {
 		return err
@@ -413,9 +480,17 @@ func (r *PostgresStorage) Delete(ctx context.Context, id interface{}) error {
 		"id":        id,
 		"deletedAt": time.Now().UTC(),
 	}
-	_, err = statement.Exec(deleteArgs)
+	result, err := statement.Exec(deleteArgs)
 	if err != nil {
 		return err
 	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
 	return nil
 }
@@ -0,0 +1,118 @@
+// Package event builds the roster events and publishes them to the subscribers
+package event
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+)
+
+// Publisher is the interface that wraps the Publish method.
+//
+// Publish publishes the roster event to its subscribers.
+type Publisher interface {
+	Publish(ctx context.Context, e *entity.RosterEvent) error
+}
+
+// Publishers represents the publishers the events are published to one by one
+type Publishers []Publisher
+
+// Publish publishes the event to every publisher, the first error is returned
+func (ps Publishers) Publish(ctx context.Context, e *entity.RosterEvent) error {
+	var firstErr error
+	for _, p := range ps {
+		if err := p.Publish(ctx, e); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Publish publishes the events one by one, it stops at the first error
+func Publish(ctx context.Context, publisher Publisher, events ...*entity.RosterEvent) error {
+	if publisher == nil {
+		return nil
+	}
+	for _, e := range events {
+		if err := publisher.Publish(ctx, e); err != nil {
+			return fmt.Errorf("error when publishing %s event: %v", e.Type, err)
+		}
+	}
+	return nil
+}
+
+// Added returns the event of the player added to its team
+func Added(player *entity.Roster) *entity.RosterEvent {
+	return &entity.RosterEvent{
+		ID:         newID(),
+		Type:       entity.EventPlayerAdded,
+		TeamID:     player.TeamID,
+		Player:     player,
+		To:         string(player.Role),
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// Changed returns the events of the status and the role changes between the player versions
+func Changed(before, after *entity.Roster) []*entity.RosterEvent {
+	events := []*entity.RosterEvent{}
+	now := time.Now().UTC()
+	if before.Status != after.Status {
+		events = append(events, &entity.RosterEvent{
+			ID:         newID(),
+			Type:       entity.EventStatusChanged,
+			TeamID:     after.TeamID,
+			Player:     after,
+			From:       before.Status,
+			To:         after.Status,
+			OccurredAt: now,
+		})
+	}
+	if before.Role != after.Role {
+		events = append(events, &entity.RosterEvent{
+			ID:         newID(),
+			Type:       entity.EventRoleChanged,
+			TeamID:     after.TeamID,
+			Player:     after,
+			From:       string(before.Role),
+			To:         string(after.Role),
+			OccurredAt: now,
+		})
+	}
+	return events
+}
+
+// Transferred returns the event of the transfer of the player.
+// The team of a benched player is the team benching it,
+// the team of a retired player is the team it left.
+func Transferred(player *entity.Roster, transfer *entity.Transfer) *entity.RosterEvent {
+	e := &entity.RosterEvent{
+		ID:         newID(),
+		Type:       entity.EventPlayerTransferred,
+		TeamID:     transfer.ToTeamID,
+		Player:     player,
+		Transfer:   transfer,
+		To:         player.Status,
+		OccurredAt: time.Now().UTC(),
+	}
+
+	switch transfer.Type {
+	case entity.TransferBenching:
+		e.Type = entity.EventPlayerBenched
+	case entity.TransferRetirement:
+		e.Type = entity.EventPlayerRetired
+		e.TeamID = transfer.FromTeamID
+	}
+	return e
+}
+
+// newID returns a random event id
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
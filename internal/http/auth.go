@@ -0,0 +1,126 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+)
+
+// AccessTokenHeader is the header carrying the API key or the token, as an alternative to Authorization
+const AccessTokenHeader = "X-Access-Token"
+
+var (
+	// ErrNoCredentials is returned when the request doesn't carry the credentials of the authenticator
+	ErrNoCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials is returned when the credentials are not valid
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Identity represents the authenticated user
+type Identity struct {
+	UserID int
+	Claims map[string]interface{}
+}
+
+// Authenticator authenticates the user of the request.
+// It returns ErrNoCredentials if the request doesn't carry its kind of credentials,
+// so the next authenticator can be tried.
+type Authenticator interface {
+	Authenticate(req *http.Request) (*Identity, error)
+}
+
+// Authenticators tries the authenticators in order until one finds its credentials in the request.
+// The JWT authenticator goes before the API keys, it leaves the bearer tokens that aren't JWTs to the next one.
+type Authenticators []Authenticator
+
+// Authenticate returns the identity of the first authenticator finding its credentials
+func (a Authenticators) Authenticate(req *http.Request) (*Identity, error) {
+	for _, authenticator := range a {
+		identity, err := authenticator.Authenticate(req)
+		if err == ErrNoCredentials {
+			continue
+		}
+		return identity, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// Authenticate is the middleware that authenticates the requests,
+// the user id and the claims of the identity are set in the request context, see base.CurrentUser.
+// The requests failed to be authenticated are responded with 401 Unauthorized.
+func Authenticate(responder *Responder, authenticator Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			identity, err := authenticator.Authenticate(req)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="csgo-roster"`)
+				responder.ErrorContext(req.Context(), w, http.StatusUnauthorized, err)
+				return
+			}
+
+			ctx := context.WithValue(req.Context(), base.KeyUserID, identity.UserID)
+			ctx = context.WithValue(ctx, base.KeyClaims, identity.Claims)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// bearerToken returns the token of the Authorization header, or the X-Access-Token header if not set
+func bearerToken(req *http.Request) string {
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+			return strings.TrimSpace(auth[7:])
+		}
+		return ""
+	}
+	return strings.TrimSpace(req.Header.Get(AccessTokenHeader))
+}
+
+// APIKey represents the static API key of a user, e.g. of a partner integration
+type APIKey struct {
+	Name   string
+	Key    string
+	UserID int
+}
+
+// APIKeyAuthenticator authenticates the requests by the static API keys
+// sent as the bearer token or in the X-Access-Token header
+type APIKeyAuthenticator struct {
+	keys []APIKey
+}
+
+// Authenticate returns the identity of the API key, the claims are its "sub" and "name"
+func (a *APIKeyAuthenticator) Authenticate(req *http.Request) (*Identity, error) {
+	token := bearerToken(req)
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// all the keys are compared, so the time doesn't tell which key is closer
+	var found *APIKey
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(a.keys[i].Key), []byte(token)) == 1 {
+			found = &a.keys[i]
+		}
+	}
+	if found == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &Identity{
+		UserID: found.UserID,
+		Claims: map[string]interface{}{
+			"sub":  strconv.Itoa(found.UserID),
+			"name": found.Name,
+		},
+	}, nil
+}
+
+// NewAPIKeyAuthenticator creates a new API key authenticator
+func NewAPIKeyAuthenticator(keys ...APIKey) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{keys: keys}
+}
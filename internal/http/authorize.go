@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+	"github.com/go-chi/chi"
+)
+
+// PermissionLoader loads the permissions of the user, e.g. the permission service
+type PermissionLoader interface {
+	LoadPermissions(ctx context.Context, userID int) (*base.Permissions, error)
+}
+
+// LoadPermissions is the middleware that sets the permissions of the authenticated user in the request context,
+// see base.CurrentPermissions. It must be used after Authenticate.
+func LoadPermissions(responder *Responder, loader PermissionLoader) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			userID := base.CurrentUser(req.Context())
+			if userID == nil {
+				responder.ErrorContext(req.Context(), w, http.StatusUnauthorized, errors.New("not authenticated"))
+				return
+			}
+
+			permissions, err := loader.LoadPermissions(req.Context(), *userID)
+			if err != nil {
+				responder.ErrorContext(req.Context(), w, http.StatusInternalServerError, err)
+				return
+			}
+			ctx := context.WithValue(req.Context(), base.KeyPermissions, permissions)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// Check returns base.ForbiddenError if the current user is not allowed to do the request
+type Check func(req *http.Request) error
+
+// Require is the middleware that only serves the requests passing the check,
+// the others are responded with 403 Forbidden
+func Require(responder *Responder, check Check) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if err := check(req); err != nil {
+				responder.ErrorContext(req.Context(), w, ErrorStatus(err), err)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// CanView checks the current user can read the rosters
+func CanView(req *http.Request) error {
+	return base.AuthorizeView(req.Context())
+}
+
+// CanManageAny checks the current user manages at least one team,
+// the team of the request is checked by the service when it's not in the url
+func CanManageAny(req *http.Request) error {
+	return base.AuthorizeManager(req.Context())
+}
+
+// CanManageTeam checks the current user manages the team of the url param
+func CanManageTeam(param string) Check {
+	return func(req *http.Request) error {
+		teamID, err := strconv.Atoi(chi.URLParam(req, param))
+		if err != nil {
+			// the invalid id is responded 400 Bad Request by the handler
+			return base.AuthorizeManager(req.Context())
+		}
+		return base.AuthorizeTeam(req.Context(), teamID)
+	}
+}
+
+// IsAdmin checks the current user is an admin
+func IsAdmin(req *http.Request) error {
+	return base.AuthorizeAdmin(req.Context())
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/go-chi/chi"
+)
+
+var errInvalidID = errors.New("invalid id")
+
+// Responder is the interface that wraps the methods for writing http responses.
+//
+// Fail writes the error with the status code matching the domain error type,
+// the server errors are notified with the context, e.g. the request context.
+type Responder interface {
+	JSON(w http.ResponseWriter, status int, data interface{})
+	Error(w http.ResponseWriter, status int, err error)
+	Fail(ctx context.Context, w http.ResponseWriter, err error)
+}
+
+// failDecode writes the request body decoding error.
+// The unknown role is a validation error, the other errors are bad request.
+func failDecode(ctx context.Context, responder Responder, res http.ResponseWriter, err error) {
+	if rerr, ok := err.(*entity.UnknownRoleError); ok {
+		responder.Fail(ctx, res, base.NewValidationError("role", fmt.Sprintf("%q is unknown, must be one of %s", rerr.Value, entity.RoleNames())))
+		return
+	}
+	responder.Error(res, http.StatusBadRequest, err)
+}
+
+// idParam parses the "id" url parameter
+func idParam(req *http.Request) (int, error) {
+	return intIDParam(req, "id")
+}
+
+// intIDParam parses the id url parameter of the name, e.g. "subscriptionId"
+func intIDParam(req *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(req, name))
+	if err != nil || id <= 0 {
+		return 0, errInvalidID
+	}
+	return id, nil
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+var (
+	errInvalidPage  = errors.New("page must be a positive integer")
+	errInvalidLimit = fmt.Errorf("limit must be an integer between 1 and %d", maxLimit)
+)
+
+// pageParams parses the "page" and "limit" query parameters
+func pageParams(req *http.Request) (int, int, error) {
+	query := req.URL.Query()
+
+	page := 1
+	if v := query.Get("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 1 {
+			return 0, 0, errInvalidPage
+		}
+		page = p
+	}
+
+	limit, err := limitParam(req)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return page, limit, nil
+}
+
+// limitParam parses the "limit" query parameter
+func limitParam(req *http.Request) (int, error) {
+	v := req.URL.Query().Get("limit")
+	if v == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 || limit > maxLimit {
+		return 0, errInvalidLimit
+	}
+	return limit, nil
+}
+
+// cursorParam returns the "cursor" query parameter,
+// ok is true when the keyset pagination is requested even with an empty cursor
+func cursorParam(req *http.Request) (cursor string, ok bool) {
+	values, ok := req.URL.Query()["cursor"]
+	if !ok {
+		return "", false
+	}
+	return values[0], true
+}
+
+// setPageLinks sets the RFC 5988 Link header for the first, prev, next and last pages
+func setPageLinks(res http.ResponseWriter, req *http.Request, page, limit, totalPages int) {
+	lastPage := totalPages
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := []string{pageLink(req, 1, limit, "first")}
+	if page > 1 {
+		prevPage := page - 1
+		if prevPage > lastPage {
+			prevPage = lastPage
+		}
+		links = append(links, pageLink(req, prevPage, limit, "prev"))
+	}
+	if page < lastPage {
+		links = append(links, pageLink(req, page+1, limit, "next"))
+	}
+	links = append(links, pageLink(req, lastPage, limit, "last"))
+
+	res.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// setCursorLinks sets the RFC 5988 Link header for the first and next cursor pages
+func setCursorLinks(res http.ResponseWriter, req *http.Request, limit int, next string) {
+	links := []string{cursorLink(req, "", limit, "first")}
+	if next != "" {
+		links = append(links, cursorLink(req, next, limit, "next"))
+	}
+
+	res.Header().Set("Link", strings.Join(links, ", "))
+}
+
+func cursorLink(req *http.Request, cursor string, limit int, rel string) string {
+	query := req.URL.Query()
+	query.Del("page")
+	query.Set("cursor", cursor)
+	query.Set("limit", strconv.Itoa(limit))
+	return link(req, query, rel)
+}
+
+func pageLink(req *http.Request, page, limit int, rel string) string {
+	query := req.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("limit", strconv.Itoa(limit))
+	return link(req, query, rel)
+}
+
+// link formats a single Link header value of the request url with the given query
+func link(req *http.Request, query url.Values, rel string) string {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     req.Host,
+		Path:     req.URL.Path,
+		RawQuery: query.Encode(),
+	}
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/permission"
+)
+
+type PermissionController struct {
+	permissionService permission.IService
+	responder         Responder
+}
+
+func (c *PermissionController) GetPermissions() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		p, err := c.permissionService.GetPermissions(req.Context(), id)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, p)
+	}
+}
+
+func (c *PermissionController) GrantPermission() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		p := &entity.Permission{}
+		if err := json.NewDecoder(req.Body).Decode(p); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+		p.UserID = id
+
+		if err := c.permissionService.GrantPermission(req.Context(), p); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusCreated, p)
+	}
+}
+
+func (c *PermissionController) RevokePermission() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+		permissionID, err := intIDParam(req, "permissionId")
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := c.permissionService.RevokePermission(req.Context(), id, permissionID); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func NewPermissionController(permissionService permission.IService, responder Responder) *PermissionController {
+	return &PermissionController{permissionService: permissionService, responder: responder}
+}
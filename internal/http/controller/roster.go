@@ -1,22 +1,164 @@
 package controller
 
 import (
-	"github.com/aldyaz/csgo-roster/internal/http/response"
-	"github.com/aldyaz/csgo-roster/internal/roster"
+	"encoding/json"
 	"net/http"
+
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/roster"
 )
 
 type RosterController struct {
 	rosterService roster.IService
+	responder     Responder
 }
 
 func (c *RosterController) GetRosters() http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
-		r := c.rosterService.GetRosters()
-		response.JSON(res, http.StatusOK, r)
+		if cursor, ok := cursorParam(req); ok {
+			c.getRostersAfter(res, req, cursor)
+			return
+		}
+
+		page, limit, err := pageParams(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		r, err := c.rosterService.GetRosters(req.Context(), page, limit)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		setPageLinks(res, req, page, limit, r.Meta.TotalPages)
+		c.responder.JSON(res, http.StatusOK, r)
+	}
+}
+
+// getRostersAfter writes the rosters page using the keyset pagination
+func (c *RosterController) getRostersAfter(res http.ResponseWriter, req *http.Request, cursor string) {
+	limit, err := limitParam(req)
+	if err != nil {
+		c.responder.Error(res, http.StatusBadRequest, err)
+		return
+	}
+
+	r, err := c.rosterService.GetRostersAfter(req.Context(), cursor, limit)
+	if err == data.ErrInvalidCursor {
+		c.responder.Error(res, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		c.responder.Fail(req.Context(), res, err)
+		return
+	}
+	setCursorLinks(res, req, limit, r.Cursor.Next)
+	c.responder.JSON(res, http.StatusOK, r)
+}
+
+func (c *RosterController) GetRoster() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		r, err := c.rosterService.GetRoster(req.Context(), id)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, r)
+	}
+}
+
+func (c *RosterController) CreateRoster() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		r := &entity.Roster{}
+		if err := json.NewDecoder(req.Body).Decode(r); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+
+		if err := c.rosterService.CreateRoster(req.Context(), r); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusCreated, r)
+	}
+}
+
+// UpdateRoster replaces the whole roster
+func (c *RosterController) UpdateRoster() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		r := &entity.Roster{}
+		if err := json.NewDecoder(req.Body).Decode(r); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+		r.ID = id
+
+		if err := c.rosterService.UpdateRoster(req.Context(), r); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, r)
+	}
+}
+
+// PatchRoster updates only the fields sent in the request body
+func (c *RosterController) PatchRoster() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		r, err := c.rosterService.GetRoster(req.Context(), id)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		if err := json.NewDecoder(req.Body).Decode(r); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+		r.ID = id
+
+		if err := c.rosterService.UpdateRoster(req.Context(), r); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, r)
+	}
+}
+
+func (c *RosterController) DeleteRoster() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := c.rosterService.DeleteRoster(req.Context(), id); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
 	}
 }
 
-func NewRosterController(rosterService roster.IService) *RosterController {
-	return &RosterController{rosterService: rosterService}
+func NewRosterController(rosterService roster.IService, responder Responder) *RosterController {
+	return &RosterController{rosterService: rosterService, responder: responder}
 }
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/subscription"
+)
+
+type SubscriptionController struct {
+	subscriptionService subscription.IService
+	responder           Responder
+}
+
+func (c *SubscriptionController) GetSubscriptions() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		s, err := c.subscriptionService.GetSubscriptions(req.Context(), id)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, s)
+	}
+}
+
+func (c *SubscriptionController) CreateSubscription() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		s := &entity.Subscription{}
+		if err := json.NewDecoder(req.Body).Decode(s); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+		s.TeamID = id
+
+		if err := c.subscriptionService.CreateSubscription(req.Context(), s); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusCreated, s)
+	}
+}
+
+func (c *SubscriptionController) DeleteSubscription() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+		subscriptionID, err := intIDParam(req, "subscriptionId")
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := c.subscriptionService.DeleteSubscription(req.Context(), id, subscriptionID); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func NewSubscriptionController(subscriptionService subscription.IService, responder Responder) *SubscriptionController {
+	return &SubscriptionController{subscriptionService: subscriptionService, responder: responder}
+}
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/team"
+)
+
+type TeamController struct {
+	teamService team.IService
+	responder   Responder
+}
+
+func (c *TeamController) GetTeams() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		page, limit, err := pageParams(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		t, err := c.teamService.GetTeams(req.Context(), page, limit)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		setPageLinks(res, req, page, limit, t.Meta.TotalPages)
+		c.responder.JSON(res, http.StatusOK, t)
+	}
+}
+
+func (c *TeamController) GetTeam() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		t, err := c.teamService.GetTeam(req.Context(), id)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, t)
+	}
+}
+
+func (c *TeamController) GetTeamRoster() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		var r *entity.TeamRoster
+		if v := req.URL.Query().Get("at"); v != "" {
+			at, parseErr := entity.ParseDate(v)
+			if parseErr != nil {
+				c.responder.Error(res, http.StatusBadRequest, parseErr)
+				return
+			}
+			r, err = c.teamService.GetTeamRosterAt(req.Context(), id, at)
+		} else {
+			r, err = c.teamService.GetTeamRoster(req.Context(), id)
+		}
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, r)
+	}
+}
+
+// ValidateLineup checks the proposed lineup changes without saving them
+func (c *TeamController) ValidateLineup() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		l := &entity.LineupChangeList{}
+		if err := json.NewDecoder(req.Body).Decode(l); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+
+		r, err := c.teamService.ValidateLineup(req.Context(), id, l.Changes)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, r)
+	}
+}
+
+func (c *TeamController) ChangeLineup() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		l := &entity.LineupChangeList{}
+		if err := json.NewDecoder(req.Body).Decode(l); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+
+		r, err := c.teamService.ChangeLineup(req.Context(), id, l.Changes)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, r)
+	}
+}
+
+func (c *TeamController) CreateTeam() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		t := &entity.Team{}
+		if err := json.NewDecoder(req.Body).Decode(t); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+
+		if err := c.teamService.CreateTeam(req.Context(), t); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusCreated, t)
+	}
+}
+
+// UpdateTeam replaces the whole team
+func (c *TeamController) UpdateTeam() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		t := &entity.Team{}
+		if err := json.NewDecoder(req.Body).Decode(t); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+		t.ID = id
+
+		if err := c.teamService.UpdateTeam(req.Context(), t); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, t)
+	}
+}
+
+// PatchTeam updates only the fields sent in the request body
+func (c *TeamController) PatchTeam() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		t, err := c.teamService.GetTeam(req.Context(), id)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		if err := json.NewDecoder(req.Body).Decode(t); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+		t.ID = id
+
+		if err := c.teamService.UpdateTeam(req.Context(), t); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, t)
+	}
+}
+
+func (c *TeamController) DeleteTeam() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := c.teamService.DeleteTeam(req.Context(), id); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func NewTeamController(teamService team.IService, responder Responder) *TeamController {
+	return &TeamController{teamService: teamService, responder: responder}
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/transfer"
+)
+
+type TransferController struct {
+	transferService transfer.IService
+	responder       Responder
+}
+
+func (c *TransferController) GetPlayerTransfers() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		t, err := c.transferService.GetPlayerTransfers(req.Context(), id)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, t)
+	}
+}
+
+func (c *TransferController) CreatePlayerTransfer() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		t := &entity.Transfer{}
+		if err := json.NewDecoder(req.Body).Decode(t); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+		t.PlayerID = id
+
+		if err := c.transferService.CreateTransfer(req.Context(), t); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusCreated, t)
+	}
+}
+
+func NewTransferController(transferService transfer.IService, responder Responder) *TransferController {
+	return &TransferController{transferService: transferService, responder: responder}
+}
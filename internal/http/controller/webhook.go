@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/webhook"
+)
+
+type WebhookController struct {
+	webhookService webhook.IService
+	responder      Responder
+}
+
+func (c *WebhookController) GetWebhooks() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		w, err := c.webhookService.GetWebhooks(req.Context())
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, w)
+	}
+}
+
+func (c *WebhookController) GetWebhook() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		w, err := c.webhookService.GetWebhook(req.Context(), id)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, w)
+	}
+}
+
+// CreateWebhook creates the webhook, the response is the only one showing its secret
+func (c *WebhookController) CreateWebhook() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		w := &entity.Webhook{}
+		if err := json.NewDecoder(req.Body).Decode(w); err != nil {
+			failDecode(req.Context(), c.responder, res, err)
+			return
+		}
+
+		if err := c.webhookService.CreateWebhook(req.Context(), w); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusCreated, w)
+	}
+}
+
+func (c *WebhookController) DeleteWebhook() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := c.webhookService.DeleteWebhook(req.Context(), id); err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetDeliveries returns the delivery log of the webhook, up to ?limit= latest deliveries
+func (c *WebhookController) GetDeliveries() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+		limit, err := limitParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		d, err := c.webhookService.GetDeliveries(req.Context(), id, limit)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusOK, d)
+	}
+}
+
+// Redeliver queues the event of the delivery to be delivered again
+func (c *WebhookController) Redeliver() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id, err := idParam(req)
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+		deliveryID, err := intIDParam(req, "deliveryId")
+		if err != nil {
+			c.responder.Error(res, http.StatusBadRequest, err)
+			return
+		}
+
+		d, err := c.webhookService.Redeliver(req.Context(), id, deliveryID)
+		if err != nil {
+			c.responder.Fail(req.Context(), res, err)
+			return
+		}
+		c.responder.JSON(res, http.StatusAccepted, d)
+	}
+}
+
+func NewWebhookController(webhookService webhook.IService, responder Responder) *WebhookController {
+	return &WebhookController{webhookService: webhookService, responder: responder}
+}
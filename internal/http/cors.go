@@ -0,0 +1,94 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/cors"
+)
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"}
+	defaultCORSHeaders = []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Access-Token"}
+	defaultCORSExposed = []string{RequestIDHeader, "Link"}
+)
+
+const defaultCORSMaxAge = 5 * time.Minute // Maximum value not ignored by any of major browsers
+
+// CORSPolicy represent the cross-origin policy of a group of routes.
+// An origin may contain one wildcard to allow the subdomains, e.g. https://*.example.com.
+//
+// AllowCredentials is only honored with explicit origins,
+// browsers reject the credentials for the origins allowed by "*".
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           time.Duration
+	AllowCredentials bool
+}
+
+// CORSConfig represent the cross-origin policies of the server.
+// Routes replaces the Default policy for the route groups by their prefix, e.g. "/v1/webhooks",
+// so the public read endpoints and the admin endpoints can have different policies.
+type CORSConfig struct {
+	Default CORSPolicy
+	Routes  map[string]CORSPolicy
+}
+
+// cors returns the cross-origin middleware of the route group
+func (s *Server) cors(prefix string) func(http.Handler) http.Handler {
+	name := prefix
+	policy, ok := s.config.CORS.Routes[prefix]
+	if !ok {
+		name, policy = "default", s.config.CORS.Default
+	}
+	return newCors(name, policy).Handler
+}
+
+// newCors creates the cross-origin handler of the policy,
+// the values not provided are defaulted to allow any origin without the credentials.
+// For more ideas, see: https://developer.github.com/v3/#cross-origin-resource-sharing
+func newCors(name string, policy CORSPolicy) *cors.Cors {
+	if len(policy.AllowedOrigins) == 0 {
+		policy.AllowedOrigins = []string{"*"}
+	}
+	if len(policy.AllowedMethods) == 0 {
+		policy.AllowedMethods = defaultCORSMethods
+	}
+	if len(policy.AllowedHeaders) == 0 {
+		policy.AllowedHeaders = defaultCORSHeaders
+	}
+	if len(policy.ExposedHeaders) == 0 {
+		policy.ExposedHeaders = defaultCORSExposed
+	}
+	if policy.MaxAge == 0 {
+		policy.MaxAge = defaultCORSMaxAge
+	}
+	if policy.AllowCredentials && allowsAnyOrigin(policy.AllowedOrigins) {
+		log.Printf("CORS credentials of the %s policy are disabled, they require explicit origins\n", name)
+		policy.AllowCredentials = false
+	}
+
+	return cors.New(cors.Options{
+		AllowedOrigins:   policy.AllowedOrigins,
+		AllowedMethods:   policy.AllowedMethods,
+		AllowedHeaders:   policy.AllowedHeaders,
+		ExposedHeaders:   policy.ExposedHeaders,
+		MaxAge:           int(policy.MaxAge / time.Second),
+		AllowCredentials: policy.AllowCredentials,
+	})
+}
+
+// allowsAnyOrigin reports whether the origins allow any origin, a subdomain wildcard is not
+func allowsAnyOrigin(origins []string) bool {
+	for _, o := range origins {
+		if strings.TrimSpace(o) == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+)
+
+// errorCodes maps the error status codes to their machine-readable codes
+var errorCodes = map[int]string{
+	http.StatusBadRequest:                   "BadRequest",
+	http.StatusUnauthorized:                 "Unauthorized",
+	http.StatusPaymentRequired:              "PaymentRequired",
+	http.StatusForbidden:                    "Forbidden",
+	http.StatusNotFound:                     "NotFound",
+	http.StatusMethodNotAllowed:             "MethodNotAllowed",
+	http.StatusNotAcceptable:                "NotAcceptable",
+	http.StatusProxyAuthRequired:            "ProxyAuthRequired",
+	http.StatusRequestTimeout:               "RequestTimeout",
+	http.StatusConflict:                     "Conflict",
+	http.StatusGone:                         "Gone",
+	http.StatusLengthRequired:               "LengthRequired",
+	http.StatusPreconditionFailed:           "PreconditionFailed",
+	http.StatusRequestEntityTooLarge:        "RequestEntityTooLarge",
+	http.StatusRequestURITooLong:            "RequestURITooLong",
+	http.StatusUnsupportedMediaType:         "UnsupportedMediaType",
+	http.StatusRequestedRangeNotSatisfiable: "RequestedRangeNotSatisfiable",
+	http.StatusExpectationFailed:            "ExpectationFailed",
+	http.StatusTeapot:                       "Teapot",
+	http.StatusMisdirectedRequest:           "MisdirectedRequest",
+	http.StatusUnprocessableEntity:          "UnprocessableEntity",
+	http.StatusLocked:                       "Locked",
+	http.StatusFailedDependency:             "FailedDependency",
+	http.StatusTooEarly:                     "TooEarly",
+	http.StatusUpgradeRequired:              "UpgradeRequired",
+	http.StatusPreconditionRequired:         "PreconditionRequired",
+	http.StatusTooManyRequests:              "TooManyRequests",
+	http.StatusRequestHeaderFieldsTooLarge:  "RequestHeaderFieldsTooLarge",
+	http.StatusUnavailableForLegalReasons:   "UnavailableForLegalReasons",
+
+	http.StatusInternalServerError:           "InternalServerError",
+	http.StatusNotImplemented:                "NotImplemented",
+	http.StatusBadGateway:                    "BadGateway",
+	http.StatusServiceUnavailable:            "ServiceUnavailable",
+	http.StatusGatewayTimeout:                "GatewayTimeout",
+	http.StatusHTTPVersionNotSupported:       "HTTPVersionNotSupported",
+	http.StatusVariantAlsoNegotiates:         "VariantAlsoNegotiates",
+	http.StatusInsufficientStorage:           "InsufficientStorage",
+	http.StatusLoopDetected:                  "LoopDetected",
+	http.StatusNotExtended:                   "NotExtended",
+	http.StatusNetworkAuthenticationRequired: "NetworkAuthenticationRequired",
+}
+
+// ErrorResponse represents the default error response
+type ErrorResponse struct {
+	Code      string             `json:"code"`
+	Message   string             `json:"message"`
+	Details   []*base.FieldError `json:"details,omitempty"`
+	RequestID string             `json:"requestId,omitempty"`
+}
+
+// ErrorCode returns the machine-readable code of the error status
+func ErrorCode(status int) string {
+	if code, ok := errorCodes[status]; ok {
+		return code
+	}
+	if status >= 500 {
+		return "ServerError"
+	}
+	return "ClientError"
+}
+
+// ErrorStatus returns the status code matching the domain error type,
+// the domain errors wrapped with fmt.Errorf("...: %w", err) are matched too
+func ErrorStatus(err error) int {
+	var (
+		notFound   *base.NotFoundError
+		forbidden  *base.ForbiddenError
+		conflict   *base.ConflictError
+		validation *base.ValidationError
+	)
+	switch {
+	case errors.As(err, &notFound):
+		return http.StatusNotFound
+	case errors.As(err, &forbidden):
+		return http.StatusForbidden
+	case errors.As(err, &conflict):
+		return http.StatusConflict
+	case errors.As(err, &validation):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
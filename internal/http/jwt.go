@@ -0,0 +1,304 @@
+package http
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The supported JWT signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+const defaultUserIDClaim = "sub"
+
+// JWTKey represents the key verifying the tokens signed with its algorithm,
+// Secret for HS256 or PublicKey for RS256.
+// The key is only used for the tokens with the same "kid" header if ID is not empty.
+type JWTKey struct {
+	ID        string
+	Algorithm string
+	Secret    []byte
+	PublicKey *rsa.PublicKey
+}
+
+// JWTConfig represent the config needed when creating a new JWT authenticator.
+//
+// The issuer and the audience of the tokens are only checked if provided.
+// Leeway is the tolerance of the clock skew when checking the expiration.
+// UserIDClaim is the claim holding the numeric user id, "sub" by default.
+type JWTConfig struct {
+	Keys        []JWTKey
+	Issuer      string
+	Audience    string
+	Leeway      time.Duration
+	UserIDClaim string
+}
+
+// JWTAuthenticator authenticates the requests by the HS256 or RS256 signed JWTs
+// sent as the bearer token or in the X-Access-Token header
+type JWTAuthenticator struct {
+	config JWTConfig
+	now    func() time.Time
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Authenticate returns the identity of the token, the claims are the token claims.
+// The bearer tokens that are not JWTs are left to the next authenticator, e.g. the API keys.
+func (a *JWTAuthenticator) Authenticate(req *http.Request) (*Identity, error) {
+	token := bearerToken(req)
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrNoCredentials
+	}
+
+	header := &jwtHeader{}
+	if err := decodeSegment(parts[0], header); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !a.verify(header, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	claims := map[string]interface{}{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := a.validate(claims); err != nil {
+		return nil, err
+	}
+
+	userID, ok := intClaim(claims[a.config.UserIDClaim])
+	if !ok {
+		return nil, fmt.Errorf("invalid token: %s is not a user id", a.config.UserIDClaim)
+	}
+	return &Identity{UserID: userID, Claims: claims}, nil
+}
+
+// verify verifies the signature with the keys of the token algorithm,
+// the algorithm of the key must match so a public key can't be used as a HS256 secret
+func (a *JWTAuthenticator) verify(header *jwtHeader, signed, signature []byte) bool {
+	for _, key := range a.config.Keys {
+		if key.Algorithm != header.Algorithm || (key.ID != "" && header.KeyID != "" && key.ID != header.KeyID) {
+			continue
+		}
+
+		switch key.Algorithm {
+		case AlgHS256:
+			mac := hmac.New(sha256.New, key.Secret)
+			_, _ = mac.Write(signed)
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return true
+			}
+		case AlgRS256:
+			hashed := sha256.Sum256(signed)
+			if rsa.VerifyPKCS1v15(key.PublicKey, crypto.SHA256, hashed[:], signature) == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validate checks the registered claims, exp is required
+func (a *JWTAuthenticator) validate(claims map[string]interface{}) error {
+	now := a.now()
+
+	exp, ok := timeClaim(claims["exp"])
+	if !ok {
+		return errors.New("invalid token: exp is required")
+	}
+	if now.After(exp.Add(a.config.Leeway)) {
+		return errors.New("token is expired")
+	}
+	if nbf, ok := timeClaim(claims["nbf"]); ok && now.Add(a.config.Leeway).Before(nbf) {
+		return errors.New("token is not valid yet")
+	}
+
+	if a.config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.config.Issuer {
+			return errors.New("invalid token issuer")
+		}
+	}
+	if a.config.Audience != "" && !hasAudience(claims["aud"], a.config.Audience) {
+		return errors.New("invalid token audience")
+	}
+	return nil
+}
+
+// decodeSegment decodes the base64url encoded JSON segment of the token,
+// the numbers are kept as json.Number so the big ids aren't rounded
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// intClaim returns the integer of the claim, either a number or a numeric string
+func intClaim(v interface{}) (int, bool) {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	default:
+		return 0, false
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil || i <= 0 {
+		return 0, false
+	}
+	return i, true
+}
+
+// timeClaim returns the time of the claim in seconds since epoch
+func timeClaim(v interface{}) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+// hasAudience reports whether the aud claim, a string or a list, contains the audience
+func hasAudience(v interface{}, audience string) bool {
+	switch v := v.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, aud := range v {
+			if aud == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseRSAPublicKey parses the PEM encoded RSA public key, either PKIX or PKCS #1
+func ParseRSAPublicKey(b []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not a RSA public key")
+	}
+	return rsaKey, nil
+}
+
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	K         string `json:"k"`
+}
+
+// LoadJWKS loads the keys of the local JSON Web Key Set file,
+// the RSA keys are used for RS256 and the symmetric keys for HS256.
+// The keys for other uses than the signature or with other algorithms are skipped.
+func LoadJWKS(path string) ([]JWTKey, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("error when parsing JWKS %s: %v", path, err)
+	}
+
+	keys := []JWTKey{}
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch {
+		case k.KeyType == "RSA" && (k.Algorithm == "" || k.Algorithm == AlgRS256):
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("error when parsing JWKS %s: key %d has invalid n", path, i)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil || len(e) == 0 || len(e) > 4 {
+				return nil, fmt.Errorf("error when parsing JWKS %s: key %d has invalid e", path, i)
+			}
+			keys = append(keys, JWTKey{
+				ID:        k.KeyID,
+				Algorithm: AlgRS256,
+				PublicKey: &rsa.PublicKey{
+					N: new(big.Int).SetBytes(n),
+					E: int(new(big.Int).SetBytes(e).Int64()),
+				},
+			})
+
+		case k.KeyType == "oct" && (k.Algorithm == "" || k.Algorithm == AlgHS256):
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil || len(secret) == 0 {
+				return nil, fmt.Errorf("error when parsing JWKS %s: key %d has invalid k", path, i)
+			}
+			keys = append(keys, JWTKey{ID: k.KeyID, Algorithm: AlgHS256, Secret: secret})
+		}
+	}
+	return keys, nil
+}
+
+// NewJWTAuthenticator creates a new JWT authenticator
+// If the user id claim is not provided, will use "sub".
+func NewJWTAuthenticator(config JWTConfig) *JWTAuthenticator {
+	if config.UserIDClaim == "" {
+		config.UserIDClaim = defaultUserIDClaim
+	}
+
+	return &JWTAuthenticator{
+		config: config,
+		now:    time.Now,
+	}
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+)
+
+// RequestIDHeader is the header carrying the request id
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID is the middleware that assigns an id to every request.
+// The id sent by the client is kept, so the request can be traced across services.
+// It's set in the response header and the request context.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestID := req.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := context.WithValue(req.Context(), base.KeyRequestID, requestID)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Recoverer is the middleware that recovers from the panics and writes them as internal server error,
+// so they are logged and notified by the responder
+func Recoverer(responder *Responder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				err, ok := rec.(error)
+				if !ok {
+					err = fmt.Errorf("panic: %v", rec)
+				}
+				responder.ErrorContext(req.Context(), w, http.StatusInternalServerError, err)
+			}()
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -1,21 +1,18 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"runtime/debug"
 
+	"github.com/aldyaz/csgo-roster/internal/base"
 	"github.com/aldyaz/csgo-roster/internal/notif"
 )
 
-// ErrorResponse represents the default error response
-type ErrorResponse struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-}
-
 // Responder represents the http responder interface
 type Responder struct {
 	notifier notif.Notifier
@@ -37,44 +34,63 @@ func (r *Responder) HTML(w http.ResponseWriter, status int, data interface{}) {
 
 // Error writes error http response
 func (r *Responder) Error(w http.ResponseWriter, status int, err error) {
+	r.ErrorContext(context.Background(), w, status, err)
+}
+
+// ErrorContext writes error http response like Error.
+// The server errors are notified with the context, e.g. the request context.
+func (r *Responder) ErrorContext(ctx context.Context, w http.ResponseWriter, status int, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
-	var errorCode string
-	switch status {
-	case http.StatusUnauthorized:
-		errorCode = "Unauthorized"
-	case http.StatusNotFound:
-		errorCode = "NotFound"
-	case http.StatusBadRequest:
-		errorCode = "BadRequest"
-	case http.StatusUnprocessableEntity:
-		errorCode = "UnprocessableEntity"
-	case http.StatusInternalServerError:
-		errorCode = "InternalServerError"
-	}
-
-	if status == http.StatusInternalServerError {
+	requestID := w.Header().Get(RequestIDHeader)
+	if status >= http.StatusInternalServerError {
 		_ = json.NewEncoder(w).Encode(ErrorResponse{
-			Code:    errorCode,
-			Message: "Server error",
+			Code:      ErrorCode(status),
+			Message:   "Server error",
+			RequestID: requestID,
 		})
 
 		errMessage := fmt.Sprintf("%+v\n%s", err, string(debug.Stack()))
-		log.Println(errMessage)
+		if requestID != "" {
+			log.Printf("request %s: %s\n", requestID, errMessage)
+		} else {
+			log.Println(errMessage)
+		}
 		if r.notifier != nil {
-			if err := r.notifier.Notify(fmt.Sprintf("```%s```", errMessage)); err != nil {
-				log.Println("Failed to notify using slack: ", err)
+			n := &notif.Notification{
+				Level:   notif.LevelError,
+				Title:   fmt.Sprintf("%d %s: %v", status, ErrorCode(status), err),
+				Message: fmt.Sprintf("```%s```", errMessage),
+				Fields:  map[string]string{},
+			}
+			if requestID != "" {
+				n.Fields["request_id"] = requestID
+			}
+			if err := notif.Send(ctx, r.notifier, n); err != nil {
+				log.Println("Failed to notify: ", err)
 			}
 		}
 	} else {
-		_ = json.NewEncoder(w).Encode(ErrorResponse{
-			Code:    errorCode,
-			Message: err.Error(),
-		})
+		response := ErrorResponse{
+			Code:      ErrorCode(status),
+			Message:   err.Error(),
+			RequestID: requestID,
+		}
+		var verr *base.ValidationError
+		if errors.As(err, &verr) {
+			response.Details = verr.Fields
+		}
+		_ = json.NewEncoder(w).Encode(response)
 	}
 }
 
+// Fail writes error http response with the status code matching the domain error type.
+// The server errors are notified with the context, e.g. the request context.
+func (r *Responder) Fail(ctx context.Context, w http.ResponseWriter, err error) {
+	r.ErrorContext(ctx, w, ErrorStatus(err), err)
+}
+
 // NewResponder creates a new http responder
 func NewResponder(notifier notif.Notifier) *Responder {
 	return &Responder{
@@ -1,63 +1,279 @@
 package http
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"github.com/aldyaz/csgo-roster/internal/base"
 	"github.com/aldyaz/csgo-roster/internal/http/controller"
-	"github.com/aldyaz/csgo-roster/internal/http/response"
+	"github.com/aldyaz/csgo-roster/internal/permission"
 	"github.com/aldyaz/csgo-roster/internal/roster"
+	"github.com/aldyaz/csgo-roster/internal/subscription"
+	"github.com/aldyaz/csgo-roster/internal/team"
+	"github.com/aldyaz/csgo-roster/internal/transfer"
+	"github.com/aldyaz/csgo-roster/internal/webhook"
 	"github.com/go-chi/chi"
-	"github.com/rs/cors"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 )
 
+const (
+	defaultAddr            = ":8080"
+	defaultReadTimeout     = 15 * time.Second
+	defaultWriteTimeout    = 30 * time.Second
+	defaultIdleTimeout     = 60 * time.Second
+	defaultShutdownTimeout = 30 * time.Second
+)
+
+// ServerConfig represent the config of the http listener.
+//
+// The /v1 routes are only served to the users authenticated by the Authenticator, according to their permissions.
+// Without the Authenticator, the /v1 requests are refused unless AuthDisabled explicitly allows everything,
+// e.g. for the local development.
+//
+// ShutdownTimeout is the deadline for the in-flight requests to be drained on shutdown,
+// the shutdown hooks are given the same duration afterwards.
+type ServerConfig struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	MaxHeaderBytes  int
+	ShutdownTimeout time.Duration
+	CORS            CORSConfig
+	Authenticator   Authenticator
+	AuthDisabled    bool
+}
+
+// ShutdownHook is run when the server is shut down, e.g. to close the db pool
+type ShutdownHook func(ctx context.Context) error
+
 // Server represents the http server
 type Server struct {
-	rosterController *controller.RosterController
+	config                 ServerConfig
+	hooks                  []ShutdownHook
+	responder              *Responder
+	rosterController       *controller.RosterController
+	teamController         *controller.TeamController
+	transferController     *controller.TransferController
+	subscriptionController *controller.SubscriptionController
+	webhookController      *controller.WebhookController
+	permissionController   *controller.PermissionController
+	permissionService      permission.IService
 }
 
 func (s *Server) compileRouter() chi.Router {
 	router := chi.NewRouter()
-	// Basic CORS
-	// for more ideas, see: https://developer.github.com/v3/#cross-origin-resource-sharing
-	newCors := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Access-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300, // Maximum value not ignored by any of major browsers
+	router.Use(RequestID)
+	router.Use(Recoverer(s.responder))
+
+	// each route group has its own cross-origin policy, so it's not applied to the whole router.
+	// The prefixes of the groups are also listed in the config, which rejects the policies of the unknown ones.
+	router.With(s.cors("/")).Get("/", func(res http.ResponseWriter, req *http.Request) {
+		s.responder.JSON(res, http.StatusOK, map[string]string{
+			"message": "Hello World",
+		})
+	})
+
+	router.Route("/v1/rosters", func(r chi.Router) {
+		r.Use(s.cors("/v1/rosters"))
+		r.Use(s.authenticate, s.authorize)
+		r.Get("/", s.rosterController.GetRosters())
+		r.Get("/{id}", s.rosterController.GetRoster())
+
+		// the team of the player is checked by the roster service
+		r.Group(func(r chi.Router) {
+			r.Use(s.require(CanManageAny))
+			r.Post("/", s.rosterController.CreateRoster())
+			r.Put("/{id}", s.rosterController.UpdateRoster())
+			r.Patch("/{id}", s.rosterController.PatchRoster())
+			r.Delete("/{id}", s.rosterController.DeleteRoster())
+		})
 	})
 
-	router.Use(newCors.Handler)
+	router.Route("/v1/teams", func(r chi.Router) {
+		r.Use(s.cors("/v1/teams"))
+		r.Use(s.authenticate, s.authorize)
+		r.Get("/", s.teamController.GetTeams())
+		r.Get("/{id}", s.teamController.GetTeam())
+		r.Get("/{id}/roster", s.teamController.GetTeamRoster())
+		r.Get("/{id}/subscriptions", s.subscriptionController.GetSubscriptions())
+		r.With(s.require(IsAdmin)).Post("/", s.teamController.CreateTeam())
+		r.With(s.require(IsAdmin)).Delete("/{id}", s.teamController.DeleteTeam())
 
-	router.Get("/", func(res http.ResponseWriter, req *http.Request) {
-		response.JSON(res, http.StatusOK, func() {
-			fmt.Println("Hello World")
+		r.Group(func(r chi.Router) {
+			r.Use(s.require(CanManageTeam("id")))
+			r.Put("/{id}", s.teamController.UpdateTeam())
+			r.Patch("/{id}", s.teamController.PatchTeam())
+			r.Post("/{id}/roster/changes", s.teamController.ChangeLineup())
+			r.Post("/{id}/roster/validate", s.teamController.ValidateLineup())
+			r.Post("/{id}/subscriptions", s.subscriptionController.CreateSubscription())
+			r.Delete("/{id}/subscriptions/{subscriptionId}", s.subscriptionController.DeleteSubscription())
 		})
 	})
 
-	router.Get("/v1/rosters", s.rosterController.GetRosters())
+	router.Route("/v1/players", func(r chi.Router) {
+		r.Use(s.cors("/v1/players"))
+		r.Use(s.authenticate, s.authorize)
+		r.Get("/{id}/transfers", s.transferController.GetPlayerTransfers())
+		// the teams of the transfer are checked by the transfer service
+		r.With(s.require(CanManageAny)).Post("/{id}/transfers", s.transferController.CreatePlayerTransfer())
+	})
+
+	router.Route("/v1/webhooks", func(r chi.Router) {
+		r.Use(s.cors("/v1/webhooks"))
+		r.Use(s.authenticate, s.authorize)
+		r.Use(s.require(IsAdmin))
+		r.Get("/", s.webhookController.GetWebhooks())
+		r.Post("/", s.webhookController.CreateWebhook())
+		r.Get("/{id}", s.webhookController.GetWebhook())
+		r.Delete("/{id}", s.webhookController.DeleteWebhook())
+		r.Get("/{id}/deliveries", s.webhookController.GetDeliveries())
+		r.Post("/{id}/deliveries/{deliveryId}/redeliver", s.webhookController.Redeliver())
+	})
+
+	router.Route("/v1/users", func(r chi.Router) {
+		r.Use(s.cors("/v1/users"))
+		r.Use(s.authenticate, s.authorize)
+		r.Use(s.require(IsAdmin))
+		r.Get("/{id}/permissions", s.permissionController.GetPermissions())
+		r.Post("/{id}/permissions", s.permissionController.GrantPermission())
+		r.Delete("/{id}/permissions/{permissionId}", s.permissionController.RevokePermission())
+	})
 
 	return router
 }
 
-// ServeHTTP serves the http requests
-func (s *Server) ServeHTTP() {
-	r := s.compileRouter()
-	srv := http.Server{Addr: ":8080", Handler: r}
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("listen %s\n", err)
+// authenticate is the middleware authenticating the requests, they're all refused if no authenticator is provided
+// unless the authentication is disabled.
+// It's applied after the cross-origin middleware so the preflight requests don't need the credentials.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.config.Authenticator != nil {
+		return Authenticate(s.responder, s.config.Authenticator)(next)
+	}
+	if s.config.AuthDisabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="csgo-roster"`)
+		s.responder.ErrorContext(req.Context(), w, http.StatusUnauthorized, errors.New("authentication is not configured"))
+	})
+}
+
+// authorize is the middleware loading the permissions of the authenticated user, who must be allowed to view at least.
+// Everything is allowed if the authentication is disabled.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	if s.config.Authenticator == nil && s.config.AuthDisabled {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := context.WithValue(req.Context(), base.KeyPermissions, &base.Permissions{Admin: true})
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+	return LoadPermissions(s.responder, s.permissionService)(s.require(CanView)(next))
+}
+
+// require is the middleware only serving the requests passing the check
+func (s *Server) require(check Check) func(http.Handler) http.Handler {
+	return Require(s.responder, check)
+}
+
+// OnShutdown registers the hook to be run after the in-flight requests are drained.
+// The hooks are run in the order they're registered.
+func (s *Server) OnShutdown(hook ShutdownHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// ServeHTTP serves the http requests until SIGINT or SIGTERM is received,
+// then it stops accepting new requests, waits for the in-flight ones and runs the shutdown hooks.
+// It returns the error of the listener if it can't serve.
+func (s *Server) ServeHTTP() error {
+	srv := &http.Server{
+		Addr:           s.config.Addr,
+		Handler:        s.compileRouter(),
+		ReadTimeout:    s.config.ReadTimeout,
+		WriteTimeout:   s.config.WriteTimeout,
+		IdleTimeout:    s.config.IdleTimeout,
+		MaxHeaderBytes: s.config.MaxHeaderBytes,
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s\n", s.config.Addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		s.runHooks()
+		return err
+	case sig := <-quit:
+		log.Printf("received %s, shutting down\n", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Println("Failed to drain the requests: ", err)
+	}
+	s.runHooks()
+	return nil
+}
+
+// runHooks runs the shutdown hooks, a failed hook doesn't prevent the next ones to run
+func (s *Server) runHooks() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	for _, hook := range s.hooks {
+		if err := hook(ctx); err != nil {
+			log.Println("Failed to run the shutdown hook: ", err)
+		}
 	}
-	quit := make(chan os.Signal)
-	signal.Notify(quit, os.Interrupt, os.Kill)
-	<-quit
 }
 
 // NewServer create a new http server
-func NewServer(rosterService roster.IService) *Server {
-	rosterController := controller.NewRosterController(rosterService)
-	return &Server{rosterController: rosterController}
+// If the address is not provided, will listen on :8080.
+// If the timeouts are not provided, will use 15secs read, 30secs write, 60secs idle and 30secs shutdown timeouts.
+// If the CORS policy is not provided, will allow any origin without the credentials.
+func NewServer(config ServerConfig, responder *Responder, rosterService roster.IService, teamService team.IService, transferService transfer.IService, subscriptionService subscription.IService, webhookService webhook.IService, permissionService permission.IService) *Server {
+	rosterController := controller.NewRosterController(rosterService, responder)
+	teamController := controller.NewTeamController(teamService, responder)
+	transferController := controller.NewTransferController(transferService, responder)
+	subscriptionController := controller.NewSubscriptionController(subscriptionService, responder)
+	webhookController := controller.NewWebhookController(webhookService, responder)
+	permissionController := controller.NewPermissionController(permissionService, responder)
+	if config.Addr == "" {
+		config.Addr = defaultAddr
+	}
+	if config.ReadTimeout == 0 {
+		config.ReadTimeout = defaultReadTimeout
+	}
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = defaultWriteTimeout
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = defaultIdleTimeout
+	}
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	return &Server{
+		config:                 config,
+		responder:              responder,
+		rosterController:       rosterController,
+		teamController:         teamController,
+		transferController:     transferController,
+		subscriptionController: subscriptionController,
+		webhookController:      webhookController,
+		permissionController:   permissionController,
+		permissionService:      permissionService,
+	}
 }
@@ -0,0 +1,105 @@
+package lineup
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+)
+
+// ErrTeamNotFound is returned when the checked team doesn't exist
+var ErrTeamNotFound = &base.NotFoundError{Resource: "team"}
+
+// Checker checks the changes of the team lineups against the rules
+type Checker struct {
+	rules         Rules
+	teamStorage   data.GenericStorage
+	rosterStorage data.GenericStorage
+}
+
+// Check checks the lineup of the team as if the changed players were saved.
+// The changed players replace the current ones having the same id,
+// the new players have zero id and the players not in the team anymore are removed.
+//
+// Only the rules the change breaks further are violations, e.g. a 6th active player,
+// the rules the team already broke before by as many players or more are warnings,
+// so a team that doesn't satisfy the rules yet can still be completed step by step.
+//
+// In a transaction, the team is locked until it's committed,
+// so the concurrent changes of the team are checked one after the other.
+func (c *Checker) Check(ctx context.Context, teamID int, changed ...*entity.Roster) (*entity.LineupCheck, error) {
+	err := c.teamStorage.FindByID(ctx, &entity.Team{}, teamID)
+	if err == sql.ErrNoRows {
+		return nil, ErrTeamNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	current := []*entity.Roster{}
+	err = c.rosterStorage.Where(ctx, &current, `"teamId" = :teamId ORDER BY "id"`, map[string]interface{}{
+		"teamId": teamID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	players := proposed(teamID, current, changed)
+	before, after := c.rules.gaps(count(current)), c.rules.gaps(count(players))
+
+	result := &entity.LineupCheck{
+		Violations: []*entity.RuleViolation{},
+		Warnings:   []*entity.RuleViolation{},
+	}
+	for _, v := range c.rules.Check(players) {
+		if after[v.Rule] <= before[v.Rule] {
+			result.Warnings = append(result.Warnings, v)
+		} else {
+			result.Violations = append(result.Violations, v)
+		}
+	}
+	result.Valid = len(result.Violations) == 0
+	return result, nil
+}
+
+// Enforce checks the change and returns a validation error listing every rule it breaks
+func (c *Checker) Enforce(ctx context.Context, teamID int, changed ...*entity.Roster) error {
+	result, err := c.Check(ctx, teamID, changed...)
+	if err != nil {
+		return err
+	}
+
+	verr := &base.ValidationError{}
+	for _, v := range result.Violations {
+		verr.AddCode("lineup", v.Rule, v.Message)
+	}
+	return verr.Err()
+}
+
+// proposed applies the changed players to the current team players
+func proposed(teamID int, current []*entity.Roster, changed []*entity.Roster) []*entity.Roster {
+	changes := map[int]*entity.Roster{}
+	players := []*entity.Roster{}
+	for _, p := range changed {
+		if p.ID != 0 {
+			changes[p.ID] = p
+		}
+		if p.TeamID != nil && *p.TeamID == teamID {
+			players = append(players, p)
+		}
+	}
+
+	for _, p := range current {
+		if _, ok := changes[p.ID]; !ok {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+// NewChecker creates a new lineup checker
+func NewChecker(rules Rules, teamStorage, rosterStorage data.GenericStorage) *Checker {
+	return &Checker{rules: rules, teamStorage: teamStorage, rosterStorage: rosterStorage}
+}
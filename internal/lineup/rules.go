@@ -0,0 +1,121 @@
+package lineup
+
+import (
+	"fmt"
+
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+)
+
+// The rule names
+const (
+	RuleActivePlayers = "active_players"
+	RulePrimaryAWPers = "primary_awpers"
+	RuleIGLs          = "igls"
+	RuleSubstitutes   = "substitutes"
+)
+
+// Rules represents the composition constraints of a team lineup.
+// A negative value disables the rule.
+type Rules struct {
+	ActivePlayers    int
+	MaxPrimaryAWPers int
+	MinIGLs          int
+	MaxSubstitutes   int
+}
+
+// DefaultRules returns the standard competitive lineup rules
+func DefaultRules() Rules {
+	return Rules{
+		ActivePlayers:    5,
+		MaxPrimaryAWPers: 1,
+		MinIGLs:          1,
+		MaxSubstitutes:   2,
+	}
+}
+
+// Check checks the team players against the rules and returns every broken rule
+func (r Rules) Check(players []*entity.Roster) []*entity.RuleViolation {
+	c := count(players)
+	gaps := r.gaps(c)
+
+	violations := []*entity.RuleViolation{}
+	if gaps[RuleActivePlayers] > 0 {
+		violations = append(violations, &entity.RuleViolation{
+			Rule:    RuleActivePlayers,
+			Message: fmt.Sprintf("must have exactly %d active players, has %d", r.ActivePlayers, c.active),
+		})
+	}
+	if gaps[RulePrimaryAWPers] > 0 {
+		violations = append(violations, &entity.RuleViolation{
+			Rule:    RulePrimaryAWPers,
+			Message: fmt.Sprintf("must have at most %d primary AWPers, has %d", r.MaxPrimaryAWPers, c.awpers),
+		})
+	}
+	if gaps[RuleIGLs] > 0 {
+		violations = append(violations, &entity.RuleViolation{
+			Rule:    RuleIGLs,
+			Message: fmt.Sprintf("must have at least %d IGLs, has %d", r.MinIGLs, c.igls),
+		})
+	}
+	if gaps[RuleSubstitutes] > 0 {
+		violations = append(violations, &entity.RuleViolation{
+			Rule:    RuleSubstitutes,
+			Message: fmt.Sprintf("must have at most %d substitutes, has %d", r.MaxSubstitutes, c.substitutes),
+		})
+	}
+	return violations
+}
+
+// gaps returns how many players each enabled rule is missed by, the satisfied rules have zero gap
+func (r Rules) gaps(c counts) map[string]int {
+	gaps := map[string]int{}
+	if r.ActivePlayers >= 0 {
+		gaps[RuleActivePlayers] = abs(c.active - r.ActivePlayers)
+	}
+	if r.MaxPrimaryAWPers >= 0 && c.awpers > r.MaxPrimaryAWPers {
+		gaps[RulePrimaryAWPers] = c.awpers - r.MaxPrimaryAWPers
+	}
+	if r.MinIGLs >= 0 && c.igls < r.MinIGLs {
+		gaps[RuleIGLs] = r.MinIGLs - c.igls
+	}
+	if r.MaxSubstitutes >= 0 && c.substitutes > r.MaxSubstitutes {
+		gaps[RuleSubstitutes] = c.substitutes - r.MaxSubstitutes
+	}
+	return gaps
+}
+
+// counts represents the number of players of a lineup counted by the rules
+type counts struct {
+	active      int
+	awpers      int
+	igls        int
+	substitutes int
+}
+
+func count(players []*entity.Roster) counts {
+	c := counts{}
+	for _, p := range players {
+		switch {
+		case p.Status == entity.StatusBenched || p.Status == entity.StatusInactive:
+		case p.Role == entity.RoleCoach || p.Role == entity.RoleAnalyst:
+		case p.Status == entity.StatusSubstitute || p.Role == entity.RoleSubstitute:
+			c.substitutes++
+		default:
+			c.active++
+			if p.Role == entity.RoleAWP {
+				c.awpers++
+			}
+			if p.Role == entity.RoleIGL || p.SecondaryRoles.Has(entity.RoleIGL) {
+				c.igls++
+			}
+		}
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,144 @@
+package notif
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+const defaultAsyncQueueSize = 100
+
+// ErrQueueFull is returned when the message is dropped because the queue is full
+var ErrQueueFull = errors.New("notification queue is full")
+
+// ErrNotifierClosed is returned when notifying after the notifier is shut down
+var ErrNotifierClosed = errors.New("notifier is closed")
+
+// AsyncNotifierConfig represent the config needed when creating a new async notifier.
+// The notifications failed to be sent are passed to OnError, they are logged by default.
+type AsyncNotifierConfig struct {
+	QueueSize int
+	Workers   int
+	OnError   func(n *Notification, err error)
+}
+
+// AsyncNotifier represents the notifier that queues the messages,
+// so the caller doesn't wait for the slow notifiers.
+// The queued messages are notified by the background workers.
+type AsyncNotifier struct {
+	notifier Notifier
+	onError  func(n *Notification, err error)
+	queue    chan asyncJob
+	wg       sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// asyncJob represents the queued notification
+type asyncJob struct {
+	ctx context.Context
+	n   *Notification
+}
+
+// Notify queues the message as an error notification
+func (an *AsyncNotifier) Notify(message string) error {
+	return an.Send(context.Background(), messageNotification(message))
+}
+
+// Send queues the notification without waiting it to be sent.
+// The notification is sent with the context values but not its cancellation,
+// since the request context is canceled as soon as the response is written.
+// The notification is dropped and ErrQueueFull is returned if the queue is full.
+func (an *AsyncNotifier) Send(ctx context.Context, n *Notification) error {
+	an.mu.RLock()
+	defer an.mu.RUnlock()
+	if an.closed {
+		return ErrNotifierClosed
+	}
+
+	select {
+	case an.queue <- asyncJob{ctx: detachedContext{ctx}, n: n}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting new messages and waits for the queued messages to be notified,
+// then the notifier is flushed if it's a Flusher.
+// It returns the context error if the context is done before the queue is drained.
+func (an *AsyncNotifier) Shutdown(ctx context.Context) error {
+	an.mu.Lock()
+	if !an.closed {
+		an.closed = true
+		close(an.queue)
+	}
+	an.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		an.wg.Wait()
+		if f, ok := an.notifier.(Flusher); ok {
+			f.Flush()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// detachedContext keeps the values of the parent context but is never canceled,
+// so the notifications queued by a request are still sent once it's done
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+func (an *AsyncNotifier) work() {
+	defer an.wg.Done()
+	for job := range an.queue {
+		if err := Send(job.ctx, an.notifier, job.n); err != nil {
+			an.onError(job.n, err)
+		}
+	}
+}
+
+// NewAsyncNotifier creates a new async notifier and starts its workers.
+// If the queue size is not provided, will queue up to 100 messages.
+// If the workers is not provided, will use a single worker.
+func NewAsyncNotifier(notifier Notifier, config AsyncNotifierConfig) *AsyncNotifier {
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultAsyncQueueSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.OnError == nil {
+		config.OnError = func(n *Notification, err error) {
+			log.Println("Failed to notify: ", err)
+		}
+	}
+
+	an := &AsyncNotifier{
+		notifier: notifier,
+		onError:  config.OnError,
+		queue:    make(chan asyncJob, config.QueueSize),
+	}
+	an.wg.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go an.work()
+	}
+	return an
+}
@@ -0,0 +1,111 @@
+package notif
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type ctxKey string
+
+func TestAsyncNotifierQueueFull(t *testing.T) {
+	r := newRecorder()
+	r.block = make(chan struct{})
+	an := NewAsyncNotifier(r, AsyncNotifierConfig{QueueSize: 1, Workers: 1})
+
+	ctx := context.Background()
+	if err := an.Send(ctx, &Notification{Title: "first"}); err != nil {
+		t.Fatalf("Send() first error = %v", err)
+	}
+	<-r.started // the worker is sending the first one, the queue is empty
+	if err := an.Send(ctx, &Notification{Title: "second"}); err != nil {
+		t.Fatalf("Send() second error = %v", err)
+	}
+	if err := an.Send(ctx, &Notification{Title: "third"}); err != ErrQueueFull {
+		t.Fatalf("Send() third error = %v, want ErrQueueFull", err)
+	}
+
+	close(r.block)
+	if err := an.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	sent := r.notifications()
+	if len(sent) != 2 || sent[0].Title != "first" || sent[1].Title != "second" {
+		t.Errorf("got %d notifications, want first and second only", len(sent))
+	}
+}
+
+func TestAsyncNotifierOnError(t *testing.T) {
+	r := newRecorder()
+	r.err = errors.New("slack is down")
+	failed := make(chan error, 1)
+	an := NewAsyncNotifier(r, AsyncNotifierConfig{
+		OnError: func(n *Notification, err error) { failed <- err },
+	})
+
+	if err := an.Notify("hello"); err != nil {
+		t.Fatalf("Notify() error = %v, want the failure reported to OnError", err)
+	}
+	select {
+	case err := <-failed:
+		if err != r.err {
+			t.Errorf("OnError got %v, want %v", err, r.err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnError was not called")
+	}
+}
+
+func TestAsyncNotifierShutdownDrains(t *testing.T) {
+	r := newRecorder()
+	an := NewAsyncNotifier(r, AsyncNotifierConfig{Workers: 2})
+
+	// the canceled request context doesn't cancel the queued notifications
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey("requestId"), "abc"))
+	cancel()
+	for i := 0; i < 10; i++ {
+		if err := an.Send(ctx, &Notification{Title: "error"}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelShutdown()
+	if err := an.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if n := len(r.notifications()); n != 10 {
+		t.Errorf("got %d notifications after Shutdown, want 10", n)
+	}
+	if !r.flushed {
+		t.Error("the notifier was not flushed")
+	}
+	for _, c := range r.ctxs {
+		if c.Err() != nil || c.Value(ctxKey("requestId")) != "abc" {
+			t.Fatalf("sent with ctx err %v value %v, want the values without the cancellation", c.Err(), c.Value(ctxKey("requestId")))
+		}
+	}
+
+	if err := an.Notify("late"); err != ErrNotifierClosed {
+		t.Errorf("Notify() after Shutdown error = %v, want ErrNotifierClosed", err)
+	}
+}
+
+func TestAsyncNotifierShutdownDeadline(t *testing.T) {
+	r := newRecorder()
+	r.block = make(chan struct{})
+	defer close(r.block)
+	an := NewAsyncNotifier(r, AsyncNotifierConfig{})
+
+	if err := an.Notify("stuck"); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	<-r.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := an.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown() error = %v, want context.DeadlineExceeded", err)
+	}
+}
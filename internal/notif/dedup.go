@@ -0,0 +1,185 @@
+package notif
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const defaultDedupWindow = time.Minute
+
+// volatilePattern matches the parts of a message that differ between the occurrences of the same error,
+// e.g. the request ids, the pointer addresses and the goroutine ids of the stack traces
+var volatilePattern = regexp.MustCompile(`0x[0-9a-fA-F]+|\b[0-9a-f]{16,}\b|goroutine \d+`)
+
+// DedupNotifierConfig represent the config needed when creating a new dedup notifier.
+//
+// The identical notifications within the Window are collapsed into one notification.
+// Key returns the identity of the notification, by default its level, channel, title and message
+// ignoring the volatile parts and the fields.
+// Limit is the maximum number of distinct notifications sent within the window,
+// the others are dropped and counted, the limit is disabled if it's zero.
+type DedupNotifierConfig struct {
+	Window time.Duration
+	Key    func(n *Notification) string
+	Limit  int
+}
+
+// DedupNotifier represents the notifier that deduplicates and rate limits the notifications.
+//
+// The first occurrence of a notification is sent immediately,
+// the repeated occurrences within the window are sent once the window ends
+// as a single "(x N occurrences)" notification.
+type DedupNotifier struct {
+	notifier Notifier
+	window   time.Duration
+	key      func(n *Notification) string
+	limit    int
+
+	mu         sync.Mutex
+	pending    map[string]*occurrence
+	sent       int
+	suppressed int
+	windowEnd  time.Time
+}
+
+// occurrence represents the repeated notification within the window
+type occurrence struct {
+	ctx   context.Context
+	n     *Notification
+	count int
+	timer *time.Timer
+}
+
+// Notify notifies the message as an error notification
+func (dn *DedupNotifier) Notify(message string) error {
+	return dn.Send(context.Background(), messageNotification(message))
+}
+
+// Send sends the first occurrence of the notification and counts the repeated ones
+func (dn *DedupNotifier) Send(ctx context.Context, n *Notification) error {
+	key := dn.key(n)
+
+	dn.mu.Lock()
+	if o, ok := dn.pending[key]; ok {
+		o.count++
+		o.n = n
+		dn.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	if now.After(dn.windowEnd) {
+		if summary := dn.resetLimit(now); summary != nil {
+			go dn.send(summary)
+		}
+	}
+	if dn.limit > 0 && dn.sent >= dn.limit {
+		dn.suppressed++
+		dn.mu.Unlock()
+		return nil
+	}
+	dn.sent++
+
+	o := &occurrence{ctx: detachedContext{ctx}, n: n, count: 1}
+	o.timer = time.AfterFunc(dn.window, func() {
+		dn.flush(key)
+	})
+	dn.pending[key] = o
+	dn.mu.Unlock()
+
+	return Send(ctx, dn.notifier, n)
+}
+
+// Flush sends the repeated notifications now instead of waiting their window to end
+func (dn *DedupNotifier) Flush() {
+	dn.mu.Lock()
+	keys := make([]string, 0, len(dn.pending))
+	for key, o := range dn.pending {
+		o.timer.Stop()
+		keys = append(keys, key)
+	}
+	dn.mu.Unlock()
+
+	for _, key := range keys {
+		dn.flush(key)
+	}
+
+	dn.mu.Lock()
+	summary := dn.resetLimit(time.Now())
+	dn.mu.Unlock()
+	if summary != nil {
+		dn.send(summary)
+	}
+}
+
+// flush ends the window of the notification and sends its repeated occurrences if any.
+// The last occurrence is sent, so its fields are the most recent ones.
+func (dn *DedupNotifier) flush(key string) {
+	dn.mu.Lock()
+	o, ok := dn.pending[key]
+	delete(dn.pending, key)
+	dn.mu.Unlock()
+
+	if !ok || o.count <= 1 {
+		return
+	}
+	summary := *o.n
+	summary.Message = fmt.Sprintf("(x %d occurrences in the last %s)\n%s", o.count, dn.window, o.n.Message)
+	if err := Send(o.ctx, dn.notifier, &summary); err != nil {
+		log.Println("Failed to notify: ", err)
+	}
+}
+
+// resetLimit starts a new rate limit window,
+// it returns the notification of the number of dropped notifications of the previous one if any.
+// It must be called with the lock held.
+func (dn *DedupNotifier) resetLimit(now time.Time) *Notification {
+	var summary *Notification
+	if dn.suppressed > 0 {
+		summary = &Notification{
+			Level:   LevelWarning,
+			Title:   "Notifications suppressed",
+			Message: fmt.Sprintf("%d notifications were suppressed by the rate limit of %d per %s", dn.suppressed, dn.limit, dn.window),
+		}
+	}
+
+	dn.sent = 0
+	dn.suppressed = 0
+	dn.windowEnd = now.Add(dn.window)
+	return summary
+}
+
+// send sends the summary notification, the failure is only logged
+func (dn *DedupNotifier) send(n *Notification) {
+	if err := Send(context.Background(), dn.notifier, n); err != nil {
+		log.Println("Failed to notify: ", err)
+	}
+}
+
+// dedupKey returns the identity of the notification ignoring the volatile parts of the message
+func dedupKey(n *Notification) string {
+	return fmt.Sprintf("%d\x00%s\x00%s\x00%s", n.Level, n.Channel, n.Title, volatilePattern.ReplaceAllString(n.Message, ""))
+}
+
+// NewDedupNotifier creates a new dedup notifier.
+// If the window is not provided, will collapse the notifications within a minute.
+func NewDedupNotifier(notifier Notifier, config DedupNotifierConfig) *DedupNotifier {
+	if config.Window <= 0 {
+		config.Window = defaultDedupWindow
+	}
+	if config.Key == nil {
+		config.Key = dedupKey
+	}
+
+	return &DedupNotifier{
+		notifier: notifier,
+		window:   config.Window,
+		key:      config.Key,
+		limit:    config.Limit,
+		pending:  map[string]*occurrence{},
+	}
+}
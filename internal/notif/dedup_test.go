@@ -0,0 +1,84 @@
+package notif
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupNotifierOccurrences(t *testing.T) {
+	r := newRecorder()
+	dn := NewDedupNotifier(r, DedupNotifierConfig{Window: time.Minute})
+
+	// the pointer addresses differ between the occurrences of the same panic
+	for _, addr := range []string{"0xc000012345", "0xc000067890", "0xc0000abcde"} {
+		err := dn.Send(context.Background(), &Notification{Title: "500 panic", Message: "nil map at " + addr})
+		if err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	if err := dn.Notify("another error"); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if n := len(r.notifications()); n != 2 {
+		t.Fatalf("got %d notifications before Flush, want the first occurrence of both", n)
+	}
+
+	dn.Flush()
+	sent := r.notifications()
+	if len(sent) != 3 {
+		t.Fatalf("got %d notifications after Flush, want 3", len(sent))
+	}
+	if !strings.HasPrefix(sent[2].Message, "(x 3 occurrences in the last 1m0s)\n") {
+		t.Errorf("summary message = %q, want the 3 occurrences", sent[2].Message)
+	}
+	if !strings.HasSuffix(sent[2].Message, "0xc0000abcde") {
+		t.Errorf("summary message = %q, want the last occurrence", sent[2].Message)
+	}
+}
+
+func TestDedupNotifierWindow(t *testing.T) {
+	r := newRecorder()
+	dn := NewDedupNotifier(r, DedupNotifierConfig{Window: 20 * time.Millisecond})
+
+	_ = dn.Notify("db down")
+	_ = dn.Notify("db down")
+
+	sent := r.waitFor(t, 2)
+	if !strings.HasPrefix(sent[1].Message, "(x 2 occurrences") {
+		t.Errorf("message after the window = %q, want the 2 occurrences", sent[1].Message)
+	}
+
+	// a new window starts once the previous one ended
+	_ = dn.Notify("db down")
+	sent = r.waitFor(t, 3)
+	if sent[2].Message != "db down" {
+		t.Errorf("message in the new window = %q, want the first occurrence", sent[2].Message)
+	}
+}
+
+func TestDedupNotifierLimit(t *testing.T) {
+	r := newRecorder()
+	dn := NewDedupNotifier(r, DedupNotifierConfig{Window: time.Minute, Limit: 2})
+
+	for _, message := range []string{"a", "b", "c", "d", "a"} {
+		if err := dn.Notify(message); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+	if n := len(r.notifications()); n != 2 {
+		t.Fatalf("got %d notifications, want the limit of 2", n)
+	}
+
+	dn.Flush()
+	sent := r.notifications()
+	if len(sent) != 4 {
+		t.Fatalf("got %d notifications after Flush, want the repeated a and the summary", len(sent))
+	}
+	summary := sent[3]
+	if summary.Level != LevelWarning || summary.Message != "2 notifications were suppressed by the rate limit of 2 per 1m0s" {
+		t.Errorf("summary = %s %q, want the 2 suppressed notifications warning", summary.Level, summary.Message)
+	}
+}
@@ -0,0 +1,244 @@
+package notif
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultEmailSubject = "[csgo-roster] {{if .Title}}{{.Title}}{{else}}{{.FirstLine}}{{end}}"
+	defaultSMTPTimeout  = 30 * time.Second
+	maxSubjectLength    = 120
+)
+
+// EmailNotifierConfig represent the config needed when creating a new email notifier.
+//
+// Subject is the text/template of the email subject,
+// it's executed with .Level, .Title, .Message, .Fields, .FirstLine and .Time.
+// If StartTLS is true, the notifier refuses to send through the server not supporting STARTTLS,
+// otherwise STARTTLS is still used when the server supports it.
+// The server is authenticated using PLAIN auth if the username is not empty.
+type EmailNotifierConfig struct {
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	To        []string
+	Subject   string
+	StartTLS  bool
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+}
+
+// EmailNotifier represents the notifier that will send the message by email through a SMTP server
+type EmailNotifier struct {
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	To        []string
+	StartTLS  bool
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+	subject   *template.Template
+}
+
+// emailData represents the data the subject template is executed with
+type emailData struct {
+	Level     string
+	Title     string
+	Message   string
+	Fields    map[string]string
+	FirstLine string
+	Time      time.Time
+}
+
+// Notify sends the message as a plain text email to all recipients
+func (en *EmailNotifier) Notify(message string) error {
+	return en.Send(context.Background(), messageNotification(message))
+}
+
+// Send sends the notification as a plain text email to all recipients,
+// the body is the notification text including its fields
+func (en *EmailNotifier) Send(ctx context.Context, n *Notification) error {
+	data := emailData{
+		Level:     n.Level.String(),
+		Title:     n.Title,
+		Message:   n.Message,
+		Fields:    n.Fields,
+		FirstLine: firstLine(n.Message),
+		Time:      time.Now().UTC(),
+	}
+
+	subject := &bytes.Buffer{}
+	if err := en.subject.Execute(subject, data); err != nil {
+		return fmt.Errorf("error when rendering email subject: %v", err)
+	}
+
+	// the subject must be a single line header
+	body, err := en.message(strings.Join(strings.Fields(subject.String()), " "), n.Text(), data.Time)
+	if err != nil {
+		return err
+	}
+	return en.send(ctx, body)
+}
+
+// message formats the email headers and the quoted-printable encoded body
+func (en *EmailNotifier) message(subject, text string, date time.Time) ([]byte, error) {
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", en.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(en.To, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(msg, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(msg)
+	if _, err := w.Write([]byte(strings.Replace(text, "\n", "\r\n", -1))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// send sends the email through the SMTP server.
+// The connection is closed when the context is done, so the conversation is aborted.
+func (en *EmailNotifier) send(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(en.Host, strconv.Itoa(en.Port))
+	dialer := &net.Dialer{Timeout: en.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(en.Timeout))
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	c, err := smtp.NewClient(conn, en.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		tlsConfig := en.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: en.Host}
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	} else if en.StartTLS {
+		return errors.New("smtp server does not support STARTTLS")
+	}
+
+	if en.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", en.Username, en.Password, en.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(en.From); err != nil {
+		return err
+	}
+	for _, to := range en.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// firstLine returns the first non empty line of the message, shortened to fit the email subject
+func firstLine(message string) string {
+	line := ""
+	for _, l := range strings.Split(message, "\n") {
+		if l = strings.TrimSpace(strings.Trim(l, "`")); l != "" {
+			line = l
+			break
+		}
+	}
+
+	if r := []rune(line); len(r) > maxSubjectLength {
+		line = string(r[:maxSubjectLength]) + "..."
+	}
+	return line
+}
+
+// NewEmailNotifier creates a new email notifier.
+// If the port is not provided, will use the submission port 587.
+// If the subject is not provided, will use the first line of the message.
+func NewEmailNotifier(config EmailNotifierConfig) (*EmailNotifier, error) {
+	if config.Host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	if config.From == "" {
+		return nil, errors.New("email sender is required")
+	}
+	if len(config.To) == 0 {
+		return nil, errors.New("email recipients are required")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	if config.Subject == "" {
+		config.Subject = defaultEmailSubject
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultSMTPTimeout
+	}
+
+	subject, err := template.New("subject").Parse(config.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("error when parsing email subject template: %v", err)
+	}
+
+	return &EmailNotifier{
+		Host:      config.Host,
+		Port:      config.Port,
+		Username:  config.Username,
+		Password:  config.Password,
+		From:      config.From,
+		To:        config.To,
+		StartTLS:  config.StartTLS,
+		TLSConfig: config.TLSConfig,
+		Timeout:   config.Timeout,
+		subject:   subject,
+	}, nil
+}
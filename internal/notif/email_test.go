@@ -0,0 +1,123 @@
+package notif
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func newSMTPServer(t *testing.T, options ...smtpOption) *smtpServer {
+	t.Helper()
+	s, err := startSMTPServer(options...)
+	if err != nil {
+		t.Fatalf("startSMTPServer() error = %v", err)
+	}
+	return s
+}
+
+func newEmailNotifier(t *testing.T, s *smtpServer, config EmailNotifierConfig) *EmailNotifier {
+	t.Helper()
+	config.Host = s.Host()
+	config.Port = s.Port()
+	config.From = "roster@example.com"
+	config.To = []string{"ops@example.com", "dev@example.com"}
+
+	n, err := NewEmailNotifier(config)
+	if err != nil {
+		t.Fatalf("NewEmailNotifier() error = %v", err)
+	}
+	return n
+}
+
+func TestEmailNotifierPlain(t *testing.T) {
+	s := newSMTPServer(t)
+	defer s.Close()
+	n := newEmailNotifier(t, s, EmailNotifierConfig{})
+
+	err := n.Send(context.Background(), &Notification{
+		Level:   LevelError,
+		Title:   "500 InternalServerError: pq: connection refused",
+		Message: "stack trace",
+		Fields:  map[string]string{"request_id": "abc"},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	messages := s.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	m := messages[0]
+	if m.From != "roster@example.com" {
+		t.Errorf("from = %q, want %q", m.From, "roster@example.com")
+	}
+	if strings.Join(m.To, ",") != "ops@example.com,dev@example.com" {
+		t.Errorf("to = %v, want both recipients", m.To)
+	}
+	if m.TLS {
+		t.Error("got the email over TLS, want the plain connection")
+	}
+	if !strings.Contains(m.Data, "Subject: [csgo-roster] 500 InternalServerError: pq: connection refused\n") {
+		t.Errorf("data doesn't contain the subject:\n%s", m.Data)
+	}
+	if !strings.Contains(m.Data, "stack trace") || !strings.Contains(m.Data, "request_id: abc") {
+		t.Errorf("data doesn't contain the message and the fields:\n%s", m.Data)
+	}
+}
+
+func TestEmailNotifierStartTLS(t *testing.T) {
+	s := newSMTPServer(t, withStartTLS(), withSMTPAuth("roster", "secret"))
+	defer s.Close()
+	n := newEmailNotifier(t, s, EmailNotifierConfig{
+		Username:  "roster",
+		Password:  "secret",
+		StartTLS:  true,
+		TLSConfig: s.ClientTLSConfig(),
+	})
+
+	if err := n.Notify("hello"); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	messages := s.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	if !messages[0].TLS {
+		t.Error("got the email over the plain connection, want TLS")
+	}
+}
+
+func TestEmailNotifierStartTLSRequired(t *testing.T) {
+	s := newSMTPServer(t)
+	defer s.Close()
+	n := newEmailNotifier(t, s, EmailNotifierConfig{StartTLS: true})
+
+	err := n.Notify("hello")
+	if err == nil || !strings.Contains(err.Error(), "does not support STARTTLS") {
+		t.Fatalf("Notify() error = %v, want the STARTTLS error", err)
+	}
+	if len(s.Messages()) != 0 {
+		t.Errorf("got %d messages, want none", len(s.Messages()))
+	}
+}
+
+func TestEmailNotifierAuthFailure(t *testing.T) {
+	s := newSMTPServer(t, withStartTLS(), withSMTPAuth("roster", "secret"))
+	defer s.Close()
+	n := newEmailNotifier(t, s, EmailNotifierConfig{
+		Username:  "roster",
+		Password:  "wrong",
+		StartTLS:  true,
+		TLSConfig: s.ClientTLSConfig(),
+	})
+
+	err := n.Notify("hello")
+	if err == nil || !strings.Contains(err.Error(), "535") {
+		t.Fatalf("Notify() error = %v, want the 535 auth error", err)
+	}
+	if len(s.Messages()) != 0 {
+		t.Errorf("got %d messages, want none", len(s.Messages()))
+	}
+}
@@ -0,0 +1,47 @@
+package notif
+
+import (
+	"context"
+	"strings"
+)
+
+// MultiError is returned when some of the notifiers fail
+type MultiError []error
+
+func (e MultiError) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// MultiNotifier represents the notifier that fans out the message to all of its notifiers
+type MultiNotifier struct {
+	notifiers []Notifier
+}
+
+// Notify notifies the message to every notifier, even if some of them fail
+func (mn *MultiNotifier) Notify(message string) error {
+	return mn.Send(context.Background(), messageNotification(message))
+}
+
+// Send sends the notification to every notifier, even if some of them fail
+func (mn *MultiNotifier) Send(ctx context.Context, n *Notification) error {
+	var errs MultiError
+	for _, notifier := range mn.notifiers {
+		if err := Send(ctx, notifier, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// NewMultiNotifier creates a new notifier fanning out to the notifiers
+func NewMultiNotifier(notifiers ...Notifier) *MultiNotifier {
+	return &MultiNotifier{notifiers: notifiers}
+}
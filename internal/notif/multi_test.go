@@ -0,0 +1,34 @@
+package notif
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMultiNotifier(t *testing.T) {
+	ok := newRecorder()
+	slack, email := newRecorder(), newRecorder()
+	slack.err = errors.New("slack is down")
+	email.err = errors.New("smtp is down")
+	mn := NewMultiNotifier(slack, ok, email)
+
+	err := mn.Send(context.Background(), &Notification{Title: "500"})
+	merr, isMulti := err.(MultiError)
+	if !isMulti {
+		t.Fatalf("Send() error = %v, want MultiError", err)
+	}
+	if len(merr) != 2 || merr[0] != slack.err || merr[1] != email.err {
+		t.Errorf("errors = %v, want both failures in order", merr)
+	}
+	if merr.Error() != "slack is down; smtp is down" {
+		t.Errorf("Error() = %q", merr.Error())
+	}
+	if len(ok.notifications()) != 1 {
+		t.Error("the working notifier didn't get the notification")
+	}
+
+	if err := NewMultiNotifier(ok, newRecorder()).Notify("hello"); err != nil {
+		t.Errorf("Notify() error = %v, want nil if all succeed", err)
+	}
+}
@@ -0,0 +1,136 @@
+package notif
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is the notifier recording the sent notifications.
+// The sends fail with err if it's set, and wait for release if block is set.
+type recorder struct {
+	err     error
+	block   chan struct{}
+	started chan struct{}
+
+	mu      sync.Mutex
+	sent    []*Notification
+	ctxs    []context.Context
+	flushed bool
+}
+
+func newRecorder() *recorder {
+	return &recorder{started: make(chan struct{}, 100)}
+}
+
+func (r *recorder) Notify(message string) error {
+	return r.Send(context.Background(), &Notification{Level: LevelError, Message: message})
+}
+
+func (r *recorder) Send(ctx context.Context, n *Notification) error {
+	r.started <- struct{}{}
+	if r.block != nil {
+		<-r.block
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+	r.ctxs = append(r.ctxs, ctx)
+	return nil
+}
+
+func (r *recorder) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushed = true
+}
+
+func (r *recorder) notifications() []*Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Notification{}, r.sent...)
+}
+
+// waitFor waits until the recorder got n notifications
+func (r *recorder) waitFor(t *testing.T, n int) []*Notification {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if sent := r.notifications(); len(sent) >= n {
+			return sent
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("got %d notifications, want %d", len(r.notifications()), n)
+	return nil
+}
+
+func TestLevelNotifierRouting(t *testing.T) {
+	tests := []struct {
+		level     Level
+		wantHook  bool
+		wantSlack bool
+		wantEmail bool
+	}{
+		{level: LevelInfo, wantHook: true},
+		{level: LevelWarning, wantHook: true, wantSlack: true},
+		{level: LevelError, wantHook: true, wantSlack: true},
+		{level: LevelCritical, wantHook: true, wantSlack: true, wantEmail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			hook, slack, email := newRecorder(), newRecorder(), newRecorder()
+			notifier := NewMultiNotifier(
+				NewLevelNotifier(hook, LevelInfo),
+				NewLevelNotifier(slack, LevelWarning),
+				NewLevelNotifier(email, LevelCritical),
+			)
+
+			if err := Send(context.Background(), notifier, &Notification{Level: tt.level, Title: "routed"}); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			for _, r := range []struct {
+				name     string
+				recorder *recorder
+				want     bool
+			}{
+				{"webhook", hook, tt.wantHook},
+				{"slack", slack, tt.wantSlack},
+				{"email", email, tt.wantEmail},
+			} {
+				sent := r.recorder.notifications()
+				if got := len(sent) == 1; got != r.want {
+					t.Errorf("%s got %d notifications, want received = %v", r.name, len(sent), r.want)
+				}
+				if len(sent) == 1 && sent[0].Level != tt.level {
+					t.Errorf("%s got level %v, want %v", r.name, sent[0].Level, tt.level)
+				}
+			}
+		})
+	}
+}
+
+func TestLevelNotifierNotify(t *testing.T) {
+	r := newRecorder()
+	if err := NewLevelNotifier(r, LevelError).Notify("db down"); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if sent := r.notifications(); len(sent) != 1 || sent[0].Level != LevelError || sent[0].Message != "db down" {
+		t.Errorf("got %+v, want the message notified as an error", sent)
+	}
+
+	r = newRecorder()
+	if err := NewLevelNotifier(r, LevelCritical).Notify("db down"); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if sent := r.notifications(); len(sent) != 0 {
+		t.Errorf("got %d notifications, want the error message dropped below critical", len(sent))
+	}
+}
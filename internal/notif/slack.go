@@ -0,0 +1,199 @@
+package notif
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const apiURL = "https://slack.com/api"
+const defaultHTTPTimeout = 80 * time.Second
+
+// slackColors are the attachment colors of the notification levels
+var slackColors = map[Level]string{
+	LevelInfo:     "#439fe0",
+	LevelWarning:  "warning",
+	LevelError:    "danger",
+	LevelCritical: "#8b0000",
+}
+
+// SlackNotifierConfig represent the config needed when creating a new slack notifier
+// The BaseURL is the slack web API url, it can point to a local stand-in for testing.
+type SlackNotifierConfig struct {
+	Token      string
+	Channel    string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// SlackNotifier represents the notifier that will notify to slack channel
+type SlackNotifier struct {
+	Token      string
+	Channel    string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// SlackMessage represents the chat.postMessage payload,
+// see https://api.slack.com/methods/chat.postMessage
+type SlackMessage struct {
+	Channel     string             `json:"channel"`
+	Text        string             `json:"text"`
+	Blocks      []*SlackBlock      `json:"blocks,omitempty"`
+	Attachments []*SlackAttachment `json:"attachments,omitempty"`
+}
+
+// SlackBlock represents a layout block of the message,
+// see https://api.slack.com/reference/messaging/blocks
+type SlackBlock struct {
+	Type   string       `json:"type"`
+	Text   *SlackText   `json:"text,omitempty"`
+	Fields []*SlackText `json:"fields,omitempty"`
+}
+
+// SlackText represents the text object of a block
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SlackAttachment represents the secondary content attached to the message,
+// see https://api.slack.com/docs/message-attachments
+type SlackAttachment struct {
+	Fallback string                  `json:"fallback,omitempty"`
+	Color    string                  `json:"color,omitempty"`
+	Title    string                  `json:"title,omitempty"`
+	Text     string                  `json:"text,omitempty"`
+	Fields   []*SlackAttachmentField `json:"fields,omitempty"`
+}
+
+// SlackAttachmentField represents the field shown as a table inside the attachment
+type SlackAttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// SlackError is returned when slack responds with {"ok": false}
+type SlackError struct {
+	Code string
+}
+
+func (e *SlackError) Error() string {
+	return fmt.Sprintf("slack error: %s", e.Code)
+}
+
+// slackResponse represents the common response body of the slack web API
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// Notify notifies message to a slack channel
+func (sn *SlackNotifier) Notify(message string) error {
+	return sn.Send(context.Background(), messageNotification(message))
+}
+
+// Send posts the notification to the slack channel as an attachment colored by its level,
+// the fields are shown as the attachment fields.
+// The notification channel is used instead of the notifier channel if it's not empty.
+func (sn *SlackNotifier) Send(ctx context.Context, n *Notification) error {
+	text := fmt.Sprintf("*%s*", strings.ToUpper(n.Level.String()))
+	if n.Title != "" {
+		text = fmt.Sprintf("%s %s", text, n.Title)
+	}
+
+	fields := []*SlackAttachmentField{}
+	for _, k := range n.fieldNames() {
+		fields = append(fields, &SlackAttachmentField{Title: k, Value: n.Fields[k], Short: true})
+	}
+
+	return sn.PostContext(ctx, &SlackMessage{
+		Channel: n.Channel,
+		Text:    text,
+		Attachments: []*SlackAttachment{{
+			Fallback: n.Text(),
+			Color:    slackColors[n.Level],
+			Text:     n.Message,
+			Fields:   fields,
+		}},
+	})
+}
+
+// Post posts the message to the slack channel.
+// The notifier channel is used if the message channel is empty.
+// It returns an error if slack doesn't accept the message.
+func (sn *SlackNotifier) Post(message *SlackMessage) error {
+	return sn.PostContext(context.Background(), message)
+}
+
+// PostContext posts the message to the slack channel like Post, giving up when the context is done.
+// The message is not modified, so the caller can reuse it.
+func (sn *SlackNotifier) PostContext(ctx context.Context, message *SlackMessage) error {
+	if message.Channel == "" {
+		m := *message
+		m.Channel = sn.Channel
+		message = &m
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/chat.postMessage", sn.BaseURL), bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", sn.Token))
+	req.Header.Set("Content-type", "application/json; charset=utf-8")
+
+	res, err := sn.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		if res.StatusCode == http.StatusTooManyRequests {
+			return fmt.Errorf("slack rate limited, retry after %ss", res.Header.Get("Retry-After"))
+		}
+		return fmt.Errorf("slack responded with status %d", res.StatusCode)
+	}
+
+	body := slackResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return fmt.Errorf("error when decoding slack response: %v", err)
+	}
+	if !body.OK {
+		return &SlackError{Code: body.Error}
+	}
+	return nil
+}
+
+// NewSlackNotifier creates a new slack notifier.
+// If the base url is not provided, will use the slack.com web API.
+// If the http client is not provided, will use the default http client with default http timeout 80secs
+func NewSlackNotifier(config SlackNotifierConfig) *SlackNotifier {
+	if config.BaseURL == "" {
+		config.BaseURL = apiURL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	return &SlackNotifier{
+		Token:      config.Token,
+		Channel:    config.Channel,
+		BaseURL:    strings.TrimSuffix(config.BaseURL, "/"),
+		HTTPClient: config.HTTPClient,
+	}
+}
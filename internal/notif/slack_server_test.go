@@ -0,0 +1,125 @@
+package notif
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// slackPost represents the message posted to the fake slack server
+type slackPost struct {
+	Token string
+	SlackMessage
+}
+
+// slackServer is the fake slack web API server recording the posted messages.
+// Use its URL as the slack notifier base url.
+type slackServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	messages   []*slackPost
+	errorCode  string
+	retryAfter time.Duration
+}
+
+// newSlackServer starts a new fake slack server, the caller should call Close when finished
+func newSlackServer() *slackServer {
+	s := &slackServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/chat.postMessage", s.postMessage)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Messages returns the messages posted successfully so far
+func (s *slackServer) Messages() []*slackPost {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := make([]*slackPost, len(s.messages))
+	copy(messages, s.messages)
+	return messages
+}
+
+// FailWith makes the server respond {"ok": false, "error": code} to the next messages,
+// e.g. "channel_not_found" or "invalid_auth"
+func (s *slackServer) FailWith(code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errorCode = code
+}
+
+// RateLimit makes the server respond 429 Too Many Requests to the next messages
+func (s *slackServer) RateLimit(retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retryAfter = retryAfter
+}
+
+// Reset clears the recorded messages and the simulated failures
+func (s *slackServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	s.errorCode = ""
+	s.retryAfter = 0
+}
+
+func (s *slackServer) postMessage(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.retryAfter > 0 {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(s.retryAfter.Seconds())))
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		respondSlack(w, "not_authed")
+		return
+	}
+	if s.errorCode != "" {
+		respondSlack(w, s.errorCode)
+		return
+	}
+
+	m := &slackPost{Token: token}
+	if err := json.NewDecoder(req.Body).Decode(&m.SlackMessage); err != nil {
+		respondSlack(w, "invalid_json")
+		return
+	}
+	if m.Channel == "" {
+		respondSlack(w, "channel_not_found")
+		return
+	}
+	if m.Text == "" && len(m.Blocks) == 0 && len(m.Attachments) == 0 {
+		respondSlack(w, "no_text")
+		return
+	}
+
+	s.messages = append(s.messages, m)
+	respondSlack(w, "")
+}
+
+// respondSlack writes the slack web API response, the request is failed if the error code is not empty
+func respondSlack(w http.ResponseWriter, errorCode string) {
+	w.Header().Set("Content-Type", "application/json")
+	body := map[string]interface{}{
+		"ok": errorCode == "",
+	}
+	if errorCode != "" {
+		body["error"] = errorCode
+	}
+	_ = json.NewEncoder(w).Encode(body)
+}
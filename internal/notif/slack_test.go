@@ -0,0 +1,151 @@
+package notif
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSlackNotifier(s *slackServer) *SlackNotifier {
+	return NewSlackNotifier(SlackNotifierConfig{
+		Token:   "xoxb-token",
+		Channel: "#alerts",
+		BaseURL: s.URL,
+	})
+}
+
+func TestSlackNotifierPost(t *testing.T) {
+	s := newSlackServer()
+	defer s.Close()
+
+	text := "500 code: \"pq: duplicate key\"\nat line 2\t\\ done"
+	err := newSlackNotifier(s).Post(&SlackMessage{Text: text})
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	messages := s.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	m := messages[0]
+	if m.Token != "xoxb-token" {
+		t.Errorf("token = %q, want %q", m.Token, "xoxb-token")
+	}
+	if m.Channel != "#alerts" {
+		t.Errorf("channel = %q, want the notifier channel %q", m.Channel, "#alerts")
+	}
+	if m.Text != text {
+		t.Errorf("text = %q, want %q", m.Text, text)
+	}
+}
+
+func TestSlackNotifierPostKeepsMessage(t *testing.T) {
+	s := newSlackServer()
+	defer s.Close()
+
+	message := &SlackMessage{Text: "hello"}
+	if err := newSlackNotifier(s).Post(message); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if message.Channel != "" {
+		t.Errorf("message channel = %q, want the caller's message unchanged", message.Channel)
+	}
+}
+
+func TestSlackNotifierSend(t *testing.T) {
+	s := newSlackServer()
+	defer s.Close()
+
+	err := newSlackNotifier(s).Send(context.Background(), &Notification{
+		Level:   LevelCritical,
+		Title:   "db down",
+		Message: "```dial tcp: \"refused\"```",
+		Fields:  map[string]string{"request_id": "abc"},
+		Channel: "#oncall",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	messages := s.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	m := messages[0]
+	if m.Channel != "#oncall" {
+		t.Errorf("channel = %q, want the notification channel %q", m.Channel, "#oncall")
+	}
+	if m.Text != "*CRITICAL* db down" {
+		t.Errorf("text = %q, want %q", m.Text, "*CRITICAL* db down")
+	}
+	if len(m.Attachments) != 1 {
+		t.Fatalf("got %d attachments, want 1", len(m.Attachments))
+	}
+	a := m.Attachments[0]
+	if a.Text != "```dial tcp: \"refused\"```" {
+		t.Errorf("attachment text = %q", a.Text)
+	}
+	if len(a.Fields) != 1 || a.Fields[0].Title != "request_id" || a.Fields[0].Value != "abc" {
+		t.Errorf("attachment fields = %+v, want request_id=abc", a.Fields)
+	}
+}
+
+func TestSlackNotifierError(t *testing.T) {
+	s := newSlackServer()
+	defer s.Close()
+	s.FailWith("channel_not_found")
+
+	err := newSlackNotifier(s).Notify("hello")
+	serr, ok := err.(*SlackError)
+	if !ok {
+		t.Fatalf("Notify() error = %v, want *SlackError", err)
+	}
+	if serr.Code != "channel_not_found" {
+		t.Errorf("error code = %q, want %q", serr.Code, "channel_not_found")
+	}
+	if len(s.Messages()) != 0 {
+		t.Errorf("got %d messages, want none", len(s.Messages()))
+	}
+}
+
+func TestSlackNotifierRateLimited(t *testing.T) {
+	s := newSlackServer()
+	defer s.Close()
+	s.RateLimit(30 * time.Second)
+
+	err := newSlackNotifier(s).Notify("hello")
+	if err == nil || !strings.Contains(err.Error(), "retry after 30s") {
+		t.Fatalf("Notify() error = %v, want the retry after 30s error", err)
+	}
+
+	s.Reset()
+	if err := newSlackNotifier(s).Notify("hello"); err != nil {
+		t.Fatalf("Notify() after reset error = %v", err)
+	}
+}
+
+func TestSlackNotifierBaseURL(t *testing.T) {
+	s := newSlackServer()
+	defer s.Close()
+
+	n := NewSlackNotifier(SlackNotifierConfig{
+		Token:   "xoxb-token",
+		Channel: "#alerts",
+		BaseURL: s.URL + "/",
+	})
+	if n.BaseURL != s.URL {
+		t.Errorf("BaseURL = %q, want the trailing slash trimmed %q", n.BaseURL, s.URL)
+	}
+	if err := n.Notify("hello"); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(s.Messages()) != 1 {
+		t.Errorf("got %d messages on the base url server, want 1", len(s.Messages()))
+	}
+
+	if d := NewSlackNotifier(SlackNotifierConfig{}); d.BaseURL != "https://slack.com/api" {
+		t.Errorf("default BaseURL = %q, want the slack web API", d.BaseURL)
+	}
+}
@@ -0,0 +1,313 @@
+package notif
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// smtpMessage represents the email received by the fake SMTP server
+type smtpMessage struct {
+	From string
+	To   []string
+	Data string
+	// TLS reports whether the email was sent after STARTTLS
+	TLS bool
+}
+
+// smtpOption configures the fake SMTP server when it's created
+type smtpOption func(s *smtpServer) error
+
+// withSMTPAuth requires the PLAIN auth with the credentials
+func withSMTPAuth(username, password string) smtpOption {
+	return func(s *smtpServer) error {
+		s.username = username
+		s.password = password
+		return nil
+	}
+}
+
+// withStartTLS supports STARTTLS with a self-signed certificate of 127.0.0.1,
+// the emails are only accepted once the connection is upgraded.
+// Use ClientTLSConfig as the client TLS config to trust the certificate.
+func withStartTLS() smtpOption {
+	return func(s *smtpServer) error {
+		cert, err := newSMTPCertificate()
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		pool.AddCert(cert.Leaf)
+
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		s.clientTLSConfig = &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+		return nil
+	}
+}
+
+// smtpServer is the SMTP server recording the received emails.
+// It doesn't support STARTTLS unless created withStartTLS, so the client must allow the plain connection.
+type smtpServer struct {
+	// Addr is the "host:port" the server listens on
+	Addr string
+
+	// the options are only set before the server starts, so they're read without the lock
+	username        string
+	password        string
+	tlsConfig       *tls.Config
+	clientTLSConfig *tls.Config
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []*smtpMessage
+}
+
+// startSMTPServer starts a new SMTP server on a random local port, the caller should call Close when finished
+func startSMTPServer(options ...smtpOption) (*smtpServer, error) {
+	s := &smtpServer{}
+	for _, option := range options {
+		if err := option(s); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s.Addr = l.Addr().String()
+	s.listener = l
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Host returns the host the server listens on
+func (s *smtpServer) Host() string {
+	host, _, _ := net.SplitHostPort(s.Addr)
+	return host
+}
+
+// Port returns the port the server listens on
+func (s *smtpServer) Port() int {
+	_, port, _ := net.SplitHostPort(s.Addr)
+	p, _ := strconv.Atoi(port)
+	return p
+}
+
+// ClientTLSConfig returns the client TLS config trusting the server certificate, nil without STARTTLS
+func (s *smtpServer) ClientTLSConfig() *tls.Config {
+	if s.clientTLSConfig == nil {
+		return nil
+	}
+	return s.clientTLSConfig.Clone()
+}
+
+// Messages returns the emails received so far
+func (s *smtpServer) Messages() []*smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := make([]*smtpMessage, len(s.messages))
+	copy(messages, s.messages)
+	return messages
+}
+
+// Close stops the server and waits for the open sessions to finish
+func (s *smtpServer) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *smtpServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.session(conn)
+		}()
+	}
+}
+
+// session handles a single SMTP session, the session is restarted on the TLS connection after STARTTLS
+func (s *smtpServer) session(conn net.Conn) {
+	defer func() { conn.Close() }()
+	c := textproto.NewConn(conn)
+	_ = c.PrintfLine("220 smtptest ESMTP ready")
+
+	secure := false
+	authenticated := s.username == ""
+	var msg *smtpMessage
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg := line, ""
+		if i := strings.Index(line, " "); i >= 0 {
+			verb, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			extensions := []string{"smtptest"}
+			if s.tlsConfig != nil && !secure {
+				extensions = append(extensions, "STARTTLS")
+			}
+			if s.username != "" {
+				extensions = append(extensions, "AUTH PLAIN")
+			}
+			for i, e := range extensions {
+				sep := "-"
+				if i == len(extensions)-1 {
+					sep = " "
+				}
+				_ = c.PrintfLine("250%s%s", sep, e)
+			}
+		case "HELO":
+			_ = c.PrintfLine("250 smtptest")
+		case "STARTTLS":
+			if s.tlsConfig == nil || secure {
+				_ = c.PrintfLine("502 5.5.2 Command not implemented")
+				continue
+			}
+			_ = c.PrintfLine("220 2.0.0 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			// the client starts over with EHLO on the TLS connection
+			conn, c = tlsConn, textproto.NewConn(tlsConn)
+			secure = true
+			authenticated = s.username == ""
+			msg = nil
+		case "AUTH":
+			if s.auth(arg) {
+				authenticated = true
+				_ = c.PrintfLine("235 2.7.0 Authentication successful")
+			} else {
+				_ = c.PrintfLine("535 5.7.8 Authentication credentials invalid")
+			}
+		case "MAIL":
+			if s.tlsConfig != nil && !secure {
+				_ = c.PrintfLine("530 5.7.0 Must issue a STARTTLS command first")
+				continue
+			}
+			if !authenticated {
+				_ = c.PrintfLine("530 5.7.0 Authentication required")
+				continue
+			}
+			msg = &smtpMessage{From: smtpAddress(arg), TLS: secure}
+			_ = c.PrintfLine("250 OK")
+		case "RCPT":
+			if msg == nil {
+				_ = c.PrintfLine("503 5.5.1 MAIL first")
+				continue
+			}
+			msg.To = append(msg.To, smtpAddress(arg))
+			_ = c.PrintfLine("250 OK")
+		case "DATA":
+			if msg == nil || len(msg.To) == 0 {
+				_ = c.PrintfLine("503 5.5.1 RCPT first")
+				continue
+			}
+			_ = c.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := ioutil.ReadAll(c.DotReader())
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = nil
+			_ = c.PrintfLine("250 OK")
+		case "RSET":
+			msg = nil
+			_ = c.PrintfLine("250 OK")
+		case "NOOP":
+			_ = c.PrintfLine("250 OK")
+		case "QUIT":
+			_ = c.PrintfLine("221 Bye")
+			return
+		default:
+			_ = c.PrintfLine("502 5.5.2 Command not implemented")
+		}
+	}
+}
+
+// auth checks the "PLAIN <base64>" credentials
+func (s *smtpServer) auth(arg string) bool {
+	parts := strings.Fields(arg)
+	if len(parts) != 2 || strings.ToUpper(parts[0]) != "PLAIN" {
+		return false
+	}
+
+	b, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	creds := strings.Split(string(b), "\x00")
+	return len(creds) == 3 && creds[1] == s.username && creds[2] == s.password
+}
+
+// smtpAddress extracts the address of "FROM:<address>" or "TO:<address>"
+func smtpAddress(arg string) string {
+	start := strings.Index(arg, "<")
+	end := strings.LastIndex(arg, ">")
+	if start < 0 || end < start {
+		return arg
+	}
+	return arg[start+1 : end]
+}
+
+// newSMTPCertificate creates the self-signed certificate of 127.0.0.1 valid for a day
+func newSMTPCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "smtptest"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
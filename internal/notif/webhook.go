@@ -0,0 +1,212 @@
+package notif
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// The headers of the signed webhook request.
+// The signature is the hex encoded HMAC-SHA256 of "{timestamp}.{body}" prefixed by "sha256=",
+// the receiver should reject the old timestamps to prevent replay.
+const (
+	SignatureHeader          = "X-Signature-256"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+)
+
+const (
+	defaultWebhookTemplate   = `{"level": {{json .Level}}, "title": {{json .Title}}, "text": {{json .Message}}, "fields": {{json .Fields}}}`
+	defaultWebhookMaxRetries = 3
+	defaultWebhookRetryDelay = time.Second
+)
+
+// WebhookNotifierConfig represent the config needed when creating a new webhook notifier.
+//
+// Template is the text/template of the JSON body, it's executed with .Level, .Title, .Message, .Fields and .Time,
+// and the json function to encode the values safely, e.g. {"text": {{json .Message}}}.
+// Secret is the key used to sign the request, the request isn't signed if it's empty.
+// The request is retried on 5xx and network errors up to MaxRetries times,
+// waiting RetryDelay that is doubled on each retry, a negative MaxRetries disables the retry.
+type WebhookNotifierConfig struct {
+	URL        string
+	Template   string
+	Secret     string
+	Headers    map[string]string
+	MaxRetries int
+	RetryDelay time.Duration
+	HTTPClient *http.Client
+}
+
+// WebhookNotifier represents the notifier that will post the message to any url
+type WebhookNotifier struct {
+	URL        string
+	Secret     string
+	Headers    map[string]string
+	MaxRetries int
+	RetryDelay time.Duration
+	HTTPClient *http.Client
+	template   *template.Template
+}
+
+// webhookData represents the data the webhook template is executed with
+type webhookData struct {
+	Level   string
+	Title   string
+	Message string
+	Fields  map[string]string
+	Time    time.Time
+}
+
+// Notify posts the message rendered with the template to the webhook url
+func (wn *WebhookNotifier) Notify(message string) error {
+	return wn.Send(context.Background(), messageNotification(message))
+}
+
+// Send posts the notification rendered with the template to the webhook url
+func (wn *WebhookNotifier) Send(ctx context.Context, n *Notification) error {
+	fields := n.Fields
+	if fields == nil {
+		fields = map[string]string{}
+	}
+
+	body := &bytes.Buffer{}
+	err := wn.template.Execute(body, webhookData{
+		Level:   n.Level.String(),
+		Title:   n.Title,
+		Message: n.Message,
+		Fields:  fields,
+		Time:    time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error when rendering webhook template: %v", err)
+	}
+	if !json.Valid(body.Bytes()) {
+		return errors.New("webhook template rendered an invalid json")
+	}
+
+	return wn.PostContext(ctx, body.Bytes())
+}
+
+// Post posts the JSON payload to the webhook url, retrying on 5xx and network errors
+func (wn *WebhookNotifier) Post(payload []byte) error {
+	return wn.PostContext(context.Background(), payload)
+}
+
+// PostContext posts the JSON payload like Post, giving up the retries when the context is done
+func (wn *WebhookNotifier) PostContext(ctx context.Context, payload []byte) error {
+	delay := wn.RetryDelay
+	var err error
+	for attempt := 0; attempt <= wn.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			delay *= 2
+		}
+
+		var retry bool
+		retry, err = wn.post(ctx, payload)
+		if !retry {
+			return err
+		}
+	}
+	return fmt.Errorf("webhook failed after %d retries: %v", wn.MaxRetries, err)
+}
+
+// post sends the request once, it returns whether the failed request should be retried
+func (wn *WebhookNotifier) post(ctx context.Context, payload []byte) (bool, error) {
+	req, err := http.NewRequest("POST", wn.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range wn.Headers {
+		req.Header.Set(k, v)
+	}
+	if wn.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(SignatureTimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(wn.Secret, timestamp, payload))
+	}
+
+	res, err := wn.HTTPClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode >= 500 {
+		return true, fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	if res.StatusCode >= 300 {
+		return false, fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return false, nil
+}
+
+// Sign returns the signature of the webhook payload sent at the unix timestamp
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(timestamp))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewWebhookNotifier creates a new webhook notifier.
+// If the template is not provided, will post {"level", "title", "text", "fields"} of the notification.
+// If the http client is not provided, will use the default http client with default http timeout 80secs
+func NewWebhookNotifier(config WebhookNotifierConfig) (*WebhookNotifier, error) {
+	if config.URL == "" {
+		return nil, errors.New("webhook url is required")
+	}
+	if config.Template == "" {
+		config.Template = defaultWebhookTemplate
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = defaultWebhookMaxRetries
+	} else if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.RetryDelay == 0 {
+		config.RetryDelay = defaultWebhookRetryDelay
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(config.Template)
+	if err != nil {
+		return nil, fmt.Errorf("error when parsing webhook template: %v", err)
+	}
+
+	return &WebhookNotifier{
+		URL:        config.URL,
+		Secret:     config.Secret,
+		Headers:    config.Headers,
+		MaxRetries: config.MaxRetries,
+		RetryDelay: config.RetryDelay,
+		HTTPClient: config.HTTPClient,
+		template:   tmpl,
+	}, nil
+}
@@ -0,0 +1,243 @@
+package notif
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookRequest represents the request received by the webhook server
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookServer is the webhook server responding the statuses in order, then 200 once they're all used
+type webhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []*webhookRequest
+	received chan struct{}
+}
+
+func newWebhookServer(statuses ...int) *webhookServer {
+	s := &webhookServer{statuses: statuses, received: make(chan struct{}, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, &webhookRequest{header: req.Header, body: body})
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+		s.received <- struct{}{}
+	}))
+	return s
+}
+
+func (s *webhookServer) Requests() []*webhookRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := make([]*webhookRequest, len(s.requests))
+	copy(requests, s.requests)
+	return requests
+}
+
+func newWebhookNotifier(t *testing.T, s *webhookServer, config WebhookNotifierConfig) *WebhookNotifier {
+	t.Helper()
+	config.URL = s.URL
+	if config.RetryDelay == 0 {
+		config.RetryDelay = time.Millisecond
+	}
+	wn, err := NewWebhookNotifier(config)
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() error = %v", err)
+	}
+	return wn
+}
+
+func TestWebhookNotifierSign(t *testing.T) {
+	s := newWebhookServer()
+	defer s.Close()
+
+	wn := newWebhookNotifier(t, s, WebhookNotifierConfig{
+		Secret:  "webhook-secret",
+		Headers: map[string]string{"X-Source": "roster"},
+	})
+	before := time.Now().Unix()
+	err := wn.Send(context.Background(), &Notification{
+		Level:   LevelWarning,
+		Title:   "slow query",
+		Message: "took \"3s\"",
+		Fields:  map[string]string{"request_id": "abc"},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	requests := s.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	r := requests[0]
+
+	timestamp := r.header.Get(SignatureTimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || unix < before || unix > time.Now().Unix() {
+		t.Errorf("timestamp = %q, want the unix time of the request", timestamp)
+	}
+	if got, want := r.header.Get(SignatureHeader), Sign("webhook-secret", timestamp, r.body); got != want {
+		t.Errorf("signature = %q, want %q of the timestamp and body", got, want)
+	}
+	if got := r.header.Get(SignatureHeader); got == Sign("other-secret", timestamp, r.body) {
+		t.Errorf("signature = %q, want it to depend on the secret", got)
+	}
+	if got := r.header.Get("X-Source"); got != "roster" {
+		t.Errorf("X-Source header = %q, want %q", got, "roster")
+	}
+	if got := r.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	var body struct {
+		Level  string            `json:"level"`
+		Title  string            `json:"title"`
+		Text   string            `json:"text"`
+		Fields map[string]string `json:"fields"`
+	}
+	if err := json.Unmarshal(r.body, &body); err != nil {
+		t.Fatalf("body %s is not a json: %v", r.body, err)
+	}
+	if body.Level != "warning" || body.Title != "slow query" || body.Text != "took \"3s\"" || body.Fields["request_id"] != "abc" {
+		t.Errorf("body = %+v, want the notification", body)
+	}
+}
+
+func TestWebhookNotifierUnsigned(t *testing.T) {
+	s := newWebhookServer()
+	defer s.Close()
+
+	if err := newWebhookNotifier(t, s, WebhookNotifierConfig{}).Notify("hello"); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	r := s.Requests()[0]
+	if r.header.Get(SignatureHeader) != "" || r.header.Get(SignatureTimestampHeader) != "" {
+		t.Errorf("got the signature headers without the secret")
+	}
+}
+
+func TestWebhookNotifierRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		requests   int
+		wantErr    bool
+	}{
+		{
+			name:     "success",
+			requests: 1,
+		},
+		{
+			name:     "retried on 5xx",
+			statuses: []int{http.StatusInternalServerError, http.StatusBadGateway},
+			requests: 3,
+		},
+		{
+			name:       "failed after the retries",
+			statuses:   []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			maxRetries: 2,
+			requests:   3,
+			wantErr:    true,
+		},
+		{
+			name:     "not retried on 4xx",
+			statuses: []int{http.StatusBadRequest},
+			requests: 1,
+			wantErr:  true,
+		},
+		{
+			name:     "not retried on redirect",
+			statuses: []int{http.StatusNotModified},
+			requests: 1,
+			wantErr:  true,
+		},
+		{
+			name:       "retry disabled",
+			statuses:   []int{http.StatusInternalServerError},
+			maxRetries: -1,
+			requests:   1,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newWebhookServer(tt.statuses...)
+			defer s.Close()
+
+			err := newWebhookNotifier(t, s, WebhookNotifierConfig{MaxRetries: tt.maxRetries}).Notify("hello")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := len(s.Requests()); got != tt.requests {
+				t.Errorf("got %d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierCanceled(t *testing.T) {
+	s := newWebhookServer(http.StatusInternalServerError)
+	defer s.Close()
+
+	wn := newWebhookNotifier(t, s, WebhookNotifierConfig{RetryDelay: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- wn.Send(ctx, &Notification{Title: "hello"})
+	}()
+
+	// cancel while waiting for the retry after the first failed request
+	<-s.received
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Send() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send() didn't return once the context was canceled")
+	}
+	if got := len(s.Requests()); got != 1 {
+		t.Errorf("got %d requests, want no retry once canceled", got)
+	}
+}
+
+func TestWebhookNotifierInvalidTemplate(t *testing.T) {
+	s := newWebhookServer()
+	defer s.Close()
+
+	wn := newWebhookNotifier(t, s, WebhookNotifierConfig{Template: `{"text": {{.Message}}}`})
+	if err := wn.Notify("not quoted"); err == nil {
+		t.Error("Notify() error = nil, want the invalid json error")
+	}
+	if got := len(s.Requests()); got != 0 {
+		t.Errorf("got %d requests, want none", got)
+	}
+}
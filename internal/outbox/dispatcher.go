@@ -0,0 +1,221 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/event"
+)
+
+const (
+	defaultDispatchInterval = time.Second
+	defaultDispatchBatch    = 50
+	defaultMaxAttempts      = 20
+	defaultRetryDelay       = 5 * time.Second
+	defaultMaxRetryDelay    = 10 * time.Minute
+)
+
+// DispatcherConfig represent the config needed when creating a new dispatcher.
+//
+// The unpublished events are polled every Interval, up to BatchSize at once.
+// The event failed to be published is retried after RetryDelay that is doubled on each attempt up to MaxRetryDelay,
+// the event is failed after MaxAttempts.
+type DispatcherConfig struct {
+	Interval      time.Duration
+	BatchSize     int
+	MaxAttempts   int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+}
+
+// errMalformedPayload is returned when the saved event can't be decoded
+var errMalformedPayload = errors.New("malformed event payload")
+
+// Dispatcher publishes the outbox events of a subscriber to its publisher in the order they're saved.
+// The subscribers have their own dispatchers, so the failures of one don't hold back the others.
+//
+// Each event is published in a transaction locking it, and it's marked as published in the same transaction.
+// So the event is published exactly once per event id to the publishers writing to the database with the context,
+// e.g. the webhook deliveries, even if several dispatchers run against the same database.
+// The publishers leaving the process, e.g. the notifiers, may see the event again
+// only if the transaction fails to commit after they're called.
+type Dispatcher struct {
+	manager       *data.Manager
+	outboxStorage data.GenericStorage
+	subscriber    string
+	publisher     event.Publisher
+	config        DispatcherConfig
+}
+
+// Run dispatches the outbox events until the context is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to dispatch outbox events to %s: %v\n", d.subscriber, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch publishes the pending events that are due, it returns the number of published events.
+// The events failed to be published are postponed, they don't block the others.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	due := []*entity.OutboxEvent{}
+	err := d.outboxStorage.Where(ctx, &due, `"subscriber" = :subscriber AND "publishedAt" IS NULL AND "failedAt" IS NULL AND "nextAttemptAt" <= :now ORDER BY "id" LIMIT :limit`, map[string]interface{}{
+		"subscriber": d.subscriber,
+		"now":        time.Now().UTC(),
+		"limit":      d.config.BatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, o := range due {
+		ok, err := d.publish(ctx, o.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return published, ctx.Err()
+			}
+			log.Printf("Failed to publish %s event %s to %s: %v\n", o.EventType, o.EventID, d.subscriber, err)
+			if err := d.postpone(ctx, o.ID, err); err != nil {
+				return published, err
+			}
+			continue
+		}
+		if ok {
+			published++
+		}
+	}
+	return published, nil
+}
+
+// publish publishes the outbox event and marks it as published in a single transaction.
+// It returns false if the event is already published by another dispatcher.
+func (d *Dispatcher) publish(ctx context.Context, id int) (bool, error) {
+	published := false
+	err := d.manager.RunInTransaction(ctx, func(tctx context.Context) error {
+		o := &entity.OutboxEvent{}
+		err := d.outboxStorage.Single(tctx, o, `"id" = :id AND "publishedAt" IS NULL AND "failedAt" IS NULL`, map[string]interface{}{
+			"id": id,
+		})
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		e := &entity.RosterEvent{}
+		if err := json.Unmarshal(o.Payload, e); err != nil {
+			return fmt.Errorf("%w: %v", errMalformedPayload, err)
+		}
+		if err := d.publisher.Publish(tctx, e); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		o.PublishedAt = &now
+		o.LastError = ""
+		if err := d.outboxStorage.Update(tctx, o); err != nil {
+			return err
+		}
+		published = true
+		return nil
+	})
+	return published, err
+}
+
+// postpone records the failed attempt and schedules the next one,
+// unless the event is published by another dispatcher in the meantime.
+// The event is failed if the error is permanent or it was the last attempt.
+func (d *Dispatcher) postpone(ctx context.Context, id int, cause error) error {
+	return d.manager.RunInTransaction(ctx, func(tctx context.Context) error {
+		o := &entity.OutboxEvent{}
+		err := d.outboxStorage.Single(tctx, o, `"id" = :id AND "publishedAt" IS NULL AND "failedAt" IS NULL`, map[string]interface{}{
+			"id": id,
+		})
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		o.Attempts++
+		o.LastError = cause.Error()
+		if permanent(cause) || o.Attempts >= d.config.MaxAttempts {
+			log.Printf("Gave up publishing %s event %s to %s after %d attempts\n", o.EventType, o.EventID, d.subscriber, o.Attempts)
+			o.FailedAt = &now
+		} else {
+			o.NextAttemptAt = now.Add(d.backoff(o.Attempts))
+		}
+		return d.outboxStorage.Update(tctx, o)
+	})
+}
+
+// permanent reports whether publishing the event again can't succeed,
+// e.g. the event is malformed or the publisher rejects it
+func permanent(err error) bool {
+	var nerr *base.NotFoundError
+	var verr *base.ValidationError
+	return errors.Is(err, errMalformedPayload) || errors.As(err, &nerr) || errors.As(err, &verr)
+}
+
+// backoff returns the delay before the next attempt
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.RetryDelay
+	for i := 1; i < attempts && delay < d.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxRetryDelay {
+		delay = d.config.MaxRetryDelay
+	}
+	return delay
+}
+
+// NewDispatcher creates a new dispatcher of the outbox events of the subscriber to its publisher.
+// If the interval is not provided, will poll the events every second.
+// If the retry is not configured, will attempt up to 20 times from 5secs up to 10mins apart.
+func NewDispatcher(manager *data.Manager, outboxStorage data.GenericStorage, subscriber string, publisher event.Publisher, config DispatcherConfig) *Dispatcher {
+	if config.Interval <= 0 {
+		config.Interval = defaultDispatchInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultDispatchBatch
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultRetryDelay
+	}
+	if config.MaxRetryDelay <= 0 {
+		config.MaxRetryDelay = defaultMaxRetryDelay
+	}
+
+	return &Dispatcher{
+		manager:       manager,
+		outboxStorage: outboxStorage,
+		subscriber:    subscriber,
+		publisher:     publisher,
+		config:        config,
+	}
+}
@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+)
+
+func TestDispatcherBackoff(t *testing.T) {
+	d := NewDispatcher(nil, nil, "webhooks", nil, DispatcherConfig{})
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: 5 * time.Second},
+		{attempts: 1, want: 5 * time.Second},
+		{attempts: 2, want: 10 * time.Second},
+		{attempts: 3, want: 20 * time.Second},
+		{attempts: 7, want: 320 * time.Second},
+		{attempts: 8, want: 10 * time.Minute},
+		{attempts: 20, want: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempts), func(t *testing.T) {
+			if got := d.backoff(tt.attempts); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	verr := &base.ValidationError{}
+	verr.Add("url", "is invalid")
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "malformed payload", err: fmt.Errorf("%w: unexpected end of JSON input", errMalformedPayload), want: true},
+		{name: "not found", err: &base.NotFoundError{Resource: "webhook"}, want: true},
+		{name: "wrapped not found", err: fmt.Errorf("publish: %w", &base.NotFoundError{Resource: "webhook"}), want: true},
+		{name: "validation", err: verr, want: true},
+		{name: "conflict", err: &base.ConflictError{Message: "already exists"}, want: false},
+		{name: "connection", err: errors.New("connection reset"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permanent(tt.err); got != tt.want {
+				t.Errorf("permanent(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
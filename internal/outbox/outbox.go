@@ -0,0 +1,47 @@
+// Package outbox saves the roster events in the transaction of the changes they describe
+// and publishes them once the transaction is committed
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+)
+
+// Outbox is the event publisher saving the events to be published by the dispatchers of the subscribers.
+// The events should be published inside the transaction of the changes,
+// so they're saved if and only if the changes are committed.
+type Outbox struct {
+	outboxStorage data.GenericStorage
+	subscribers   []string
+}
+
+// Publish saves the event to the outbox once for each subscriber
+func (o *Outbox) Publish(ctx context.Context, e *entity.RosterEvent) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	for _, subscriber := range o.subscribers {
+		err := o.outboxStorage.Insert(ctx, &entity.OutboxEvent{
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Subscriber:    subscriber,
+			Payload:       payload,
+			NextAttemptAt: e.OccurredAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewOutbox creates a new outbox of the events published to the subscribers of the names,
+// each subscriber should have its own dispatcher
+func NewOutbox(outboxStorage data.GenericStorage, subscribers ...string) *Outbox {
+	return &Outbox{outboxStorage: outboxStorage, subscribers: subscribers}
+}
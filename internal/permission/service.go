@@ -0,0 +1,156 @@
+package permission
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the postgres error code of the unique constraint violations
+const uniqueViolation = "23505"
+
+var (
+	// ErrNotFound is returned when the requested permission doesn't exist
+	ErrNotFound = &base.NotFoundError{Resource: "permission"}
+	// ErrAlreadyGranted is returned when the role is already granted to the user
+	ErrAlreadyGranted = &base.ConflictError{Message: "role is already granted to the user"}
+)
+
+type IService interface {
+	GetPermissions(ctx context.Context, userID int) (entity.PermissionList, error)
+	GrantPermission(ctx context.Context, permission *entity.Permission) error
+	RevokePermission(ctx context.Context, userID int, id int) error
+	LoadPermissions(ctx context.Context, userID int) (*base.Permissions, error)
+}
+
+// Service manages the roles granted to the users
+type Service struct {
+	permissionStorage data.GenericStorage
+	teamStorage       data.GenericStorage
+}
+
+// GetPermissions returns the permissions granted to the user
+func (s *Service) GetPermissions(ctx context.Context, userID int) (entity.PermissionList, error) {
+	permissions := []*entity.Permission{}
+	err := s.permissionStorage.Where(ctx, &permissions, `"userId" = :userId ORDER BY "id"`, map[string]interface{}{
+		"userId": userID,
+	})
+	if err != nil {
+		return entity.PermissionList{}, err
+	}
+	return entity.PermissionList{Data: permissions}, nil
+}
+
+// GrantPermission grants the role to the user, the role already granted is a conflict.
+// The concurrent grants of the same role are rejected by the unique index, so one of them is a conflict too.
+func (s *Service) GrantPermission(ctx context.Context, permission *entity.Permission) error {
+	if err := s.validate(ctx, permission); err != nil {
+		return err
+	}
+
+	existing, err := s.GetPermissions(ctx, permission.UserID)
+	if err != nil {
+		return err
+	}
+	for _, p := range existing.Data {
+		if p.Role == permission.Role && sameTeam(p.TeamID, permission.TeamID) {
+			return ErrAlreadyGranted
+		}
+	}
+
+	err = s.permissionStorage.Insert(ctx, permission)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return ErrAlreadyGranted
+	}
+	return err
+}
+
+// RevokePermission revokes the permission of the user
+func (s *Service) RevokePermission(ctx context.Context, userID int, id int) error {
+	permission := &entity.Permission{}
+	err := s.permissionStorage.FindByID(ctx, permission, id)
+	if err == sql.ErrNoRows || (err == nil && permission.UserID != userID) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.permissionStorage.Delete(ctx, id)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// LoadPermissions returns the roles of the user merged,
+// the user without any permission gets the empty permissions allowing nothing
+func (s *Service) LoadPermissions(ctx context.Context, userID int) (*base.Permissions, error) {
+	permissions, err := s.GetPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &base.Permissions{}
+	for _, permission := range permissions.Data {
+		switch permission.Role {
+		case entity.PermissionAdmin:
+			p.Admin = true
+		case entity.PermissionViewer:
+			p.Viewer = true
+		case entity.PermissionTeamManager:
+			if permission.TeamID != nil {
+				p.TeamIDs = append(p.TeamIDs, *permission.TeamID)
+			}
+		}
+	}
+	return p, nil
+}
+
+func (s *Service) validate(ctx context.Context, permission *entity.Permission) error {
+	verr := &base.ValidationError{}
+
+	if permission.UserID <= 0 {
+		verr.Add("userId", "must be a positive user id")
+	}
+	switch permission.Role {
+	case entity.PermissionTeamManager:
+		if permission.TeamID == nil {
+			verr.Add("teamId", "is required for team_manager")
+			break
+		}
+		err := s.teamStorage.FindByID(ctx, &entity.Team{}, *permission.TeamID)
+		if err == sql.ErrNoRows {
+			verr.Add("teamId", "does not exist")
+		} else if err != nil {
+			return err
+		}
+	case entity.PermissionViewer, entity.PermissionAdmin:
+		if permission.TeamID != nil {
+			verr.Add("teamId", "is only allowed for team_manager")
+		}
+	default:
+		verr.Add("role", "must be one of viewer, team_manager, admin")
+	}
+	return verr.Err()
+}
+
+func sameTeam(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func NewService(permissionStorage, teamStorage data.GenericStorage) *Service {
+	return &Service{
+		permissionStorage: permissionStorage,
+		teamStorage:       teamStorage,
+	}
+}
@@ -1,36 +1,220 @@
 package roster
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+	"github.com/aldyaz/csgo-roster/internal/data"
 	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/event"
+	"github.com/aldyaz/csgo-roster/internal/lineup"
 )
 
+// ErrNotFound is returned when the requested roster doesn't exist
+var ErrNotFound = &base.NotFoundError{Resource: "roster"}
+
 type IService interface {
-	GetRosters() entity.RosterList
-}
-
-type Service struct{}
-
-func (s *Service) GetRosters() entity.RosterList {
-	data := []*entity.Roster{
-		{
-			ID:   0,
-			Name: "Stewie2k",
-			Role: "Entry Fragger",
-		},
-		{
-			ID:   1,
-			Name: "GuardiaN",
-			Role: "AWP",
-		},
-		{
-			ID:   0,
-			Name: "device",
-			Role: "AWP",
-		},
-	}
-	return entity.RosterList{Data: data}
-}
-
-func NewService() *Service {
-	return &Service{}
+	GetRosters(ctx context.Context, page int, limit int) (entity.RosterList, error)
+	GetRostersAfter(ctx context.Context, cursor string, limit int) (entity.RosterList, error)
+	GetRoster(ctx context.Context, id int) (*entity.Roster, error)
+	CreateRoster(ctx context.Context, roster *entity.Roster) error
+	UpdateRoster(ctx context.Context, roster *entity.Roster) error
+	DeleteRoster(ctx context.Context, id int) error
+}
+
+type Service struct {
+	manager       *data.Manager
+	checker       *lineup.Checker
+	publisher     event.Publisher
+	rosterStorage data.GenericStorage
+	teamStorage   data.GenericStorage
+}
+
+// GetRosters returns the rosters of the page along with the pagination meta
+func (s *Service) GetRosters(ctx context.Context, page int, limit int) (entity.RosterList, error) {
+	total, err := s.rosterStorage.Count(ctx)
+	if err != nil {
+		return entity.RosterList{}, err
+	}
+
+	rosters := []*entity.Roster{}
+	err = s.rosterStorage.FindAll(ctx, &rosters, page, limit)
+	if err != nil {
+		return entity.RosterList{}, err
+	}
+	return entity.RosterList{Data: rosters, Meta: entity.NewPageMeta(total, page, limit)}, nil
+}
+
+// GetRostersAfter returns the rosters after the cursor along with the next page cursor
+func (s *Service) GetRostersAfter(ctx context.Context, cursor string, limit int) (entity.RosterList, error) {
+	rosters := []*entity.Roster{}
+	next, err := s.rosterStorage.FindAfter(ctx, &rosters, cursor, limit)
+	if err != nil {
+		return entity.RosterList{}, err
+	}
+	return entity.RosterList{Data: rosters, Cursor: &entity.CursorMeta{Limit: limit, Next: next}}, nil
+}
+
+func (s *Service) GetRoster(ctx context.Context, id int) (*entity.Roster, error) {
+	roster := &entity.Roster{}
+	err := s.rosterStorage.FindByID(ctx, roster, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return roster, nil
+}
+
+// CreateRoster saves the new roster, the roster joining a team is published as added to the team
+// in the same transaction. Only the managers of the team can add its players.
+func (s *Service) CreateRoster(ctx context.Context, roster *entity.Roster) error {
+	if err := authorize(ctx, roster.TeamID); err != nil {
+		return err
+	}
+	if err := s.validate(ctx, roster); err != nil {
+		return err
+	}
+
+	return s.manager.RunInTransaction(ctx, func(tctx context.Context) error {
+		if roster.TeamID == nil {
+			return s.rosterStorage.Insert(tctx, roster)
+		}
+		// the team is locked by the check, so the concurrent changes can't break the rules together
+		if err := s.checker.Enforce(tctx, *roster.TeamID, roster); err != nil {
+			return err
+		}
+		if err := s.rosterStorage.Insert(tctx, roster); err != nil {
+			return err
+		}
+		return event.Publish(tctx, s.publisher, event.Added(roster))
+	})
+}
+
+// UpdateRoster replaces the stored roster having the same id.
+// The team and the benched/inactive status can only be changed through a transfer.
+// The status and the role changes of a team player are published in the same transaction.
+func (s *Service) UpdateRoster(ctx context.Context, roster *entity.Roster) error {
+	return s.manager.RunInTransaction(ctx, func(tctx context.Context) error {
+		// the player is locked until the change is committed, then its team by the check
+		existing, err := s.GetRoster(tctx, roster.ID)
+		if err != nil {
+			return err
+		}
+		if err := authorize(tctx, existing.TeamID); err != nil {
+			return err
+		}
+		if err := s.validate(tctx, roster); err != nil {
+			return err
+		}
+		if !sameTeam(existing.TeamID, roster.TeamID) {
+			return &base.ConflictError{Message: "teamId can only be changed through a transfer"}
+		}
+		if existing.Status != roster.Status && (transferStatus(existing.Status) || transferStatus(roster.Status)) {
+			return &base.ConflictError{Message: "status can only be changed from or to benched/inactive through a transfer"}
+		}
+		if roster.TeamID == nil {
+			return s.rosterStorage.Update(tctx, roster)
+		}
+
+		if err := s.checker.Enforce(tctx, *roster.TeamID, roster); err != nil {
+			return err
+		}
+		if err := s.rosterStorage.Update(tctx, roster); err != nil {
+			return err
+		}
+		return event.Publish(tctx, s.publisher, event.Changed(existing, roster)...)
+	})
+}
+
+// DeleteRoster deletes the roster, only the managers of its team can delete it
+func (s *Service) DeleteRoster(ctx context.Context, id int) error {
+	existing, err := s.GetRoster(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := authorize(ctx, existing.TeamID); err != nil {
+		return err
+	}
+
+	err = s.rosterStorage.Delete(ctx, id)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *Service) validate(ctx context.Context, roster *entity.Roster) error {
+	verr := &base.ValidationError{}
+
+	roster.Name = strings.TrimSpace(roster.Name)
+	if roster.Name == "" {
+		verr.Add("name", "is required")
+	}
+	if roster.Role == "" {
+		verr.Add("role", "is required")
+	}
+
+	secondaryRoles := entity.RoleList{}
+	for _, r := range roster.SecondaryRoles {
+		if r == roster.Role || secondaryRoles.Has(r) {
+			verr.Add("secondaryRoles", fmt.Sprintf("has duplicate role %s", r))
+			continue
+		}
+		secondaryRoles = append(secondaryRoles, r)
+	}
+	roster.SecondaryRoles = secondaryRoles
+
+	switch roster.Status {
+	case "":
+		roster.Status = entity.StatusActive
+	case entity.StatusActive, entity.StatusSubstitute, entity.StatusBenched, entity.StatusInactive:
+	default:
+		verr.Add("status", "must be one of active, substitute, benched, inactive")
+	}
+
+	if roster.TeamID != nil {
+		err := s.teamStorage.FindByID(ctx, &entity.Team{}, *roster.TeamID)
+		if err == sql.ErrNoRows {
+			verr.Add("teamId", "does not exist")
+		} else if err != nil {
+			return err
+		}
+	}
+	return verr.Err()
+}
+
+// authorize checks the current user manages the team of the player,
+// the players without team can be managed by any team manager
+func authorize(ctx context.Context, teamID *int) error {
+	if teamID == nil {
+		return base.AuthorizeManager(ctx)
+	}
+	return base.AuthorizeTeam(ctx, *teamID)
+}
+
+func sameTeam(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// transferStatus reports whether the status is set by the transfers
+func transferStatus(status string) bool {
+	return status == entity.StatusBenched || status == entity.StatusInactive
+}
+
+func NewService(manager *data.Manager, checker *lineup.Checker, publisher event.Publisher, rosterStorage, teamStorage data.GenericStorage) *Service {
+	return &Service{
+		manager:       manager,
+		checker:       checker,
+		publisher:     publisher,
+		rosterStorage: rosterStorage,
+		teamStorage:   teamStorage,
+	}
 }
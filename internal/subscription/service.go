@@ -0,0 +1,206 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/notif"
+	"github.com/aldyaz/csgo-roster/internal/team"
+)
+
+// ErrNotFound is returned when the requested subscription doesn't exist
+var ErrNotFound = &base.NotFoundError{Resource: "subscription"}
+
+type IService interface {
+	GetSubscriptions(ctx context.Context, teamID int) (entity.SubscriptionList, error)
+	CreateSubscription(ctx context.Context, subscription *entity.Subscription) error
+	DeleteSubscription(ctx context.Context, teamID int, id int) error
+}
+
+// Service manages the team subscriptions and notifies them the roster events
+type Service struct {
+	notifier            notif.Notifier
+	subscriptionStorage data.GenericStorage
+	teamStorage         data.GenericStorage
+}
+
+// GetSubscriptions returns the subscriptions of the team
+func (s *Service) GetSubscriptions(ctx context.Context, teamID int) (entity.SubscriptionList, error) {
+	if err := s.findTeam(ctx, &entity.Team{}, teamID); err != nil {
+		return entity.SubscriptionList{}, err
+	}
+
+	subscriptions := []*entity.Subscription{}
+	err := s.subscriptionStorage.Where(ctx, &subscriptions, `"teamId" = :teamId ORDER BY "id"`, map[string]interface{}{
+		"teamId": teamID,
+	})
+	if err != nil {
+		return entity.SubscriptionList{}, err
+	}
+	return entity.SubscriptionList{Data: subscriptions}, nil
+}
+
+func (s *Service) CreateSubscription(ctx context.Context, subscription *entity.Subscription) error {
+	if err := s.findTeam(ctx, &entity.Team{}, subscription.TeamID); err != nil {
+		return err
+	}
+	if err := validate(subscription); err != nil {
+		return err
+	}
+	return s.subscriptionStorage.Insert(ctx, subscription)
+}
+
+// DeleteSubscription deletes the subscription of the team
+func (s *Service) DeleteSubscription(ctx context.Context, teamID int, id int) error {
+	subscription := &entity.Subscription{}
+	err := s.subscriptionStorage.FindByID(ctx, subscription, id)
+	if err == sql.ErrNoRows || (err == nil && subscription.TeamID != teamID) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.subscriptionStorage.Delete(ctx, id)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Publish notifies the event to the subscriptions of the affected teams accepting it.
+// The events are dropped if no notifier is configured, and for the teams deleted since.
+func (s *Service) Publish(ctx context.Context, e *entity.RosterEvent) error {
+	if s.notifier == nil {
+		return nil
+	}
+	for _, teamID := range e.TeamIDs() {
+		t := &entity.Team{}
+		err := s.findTeam(ctx, t, teamID)
+		if err == team.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		subscriptions := []*entity.Subscription{}
+		err = s.subscriptionStorage.Where(ctx, &subscriptions, `"teamId" = :teamId ORDER BY "id"`, map[string]interface{}{
+			"teamId": teamID,
+		})
+		if err != nil {
+			return err
+		}
+		if len(subscriptions) == 0 {
+			continue
+		}
+
+		title, err := s.summary(ctx, t, e)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subscriptions {
+			if !sub.Accepts(e.Type) {
+				continue
+			}
+			err := notif.Send(ctx, s.notifier, &notif.Notification{
+				Level:   notif.LevelInfo,
+				Title:   title,
+				Channel: sub.Channel,
+				Fields: map[string]string{
+					"event":  e.Type,
+					"team":   t.Name,
+					"player": e.Player.Name,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// summary formats the event as seen by the team, e.g. "NaVi: s1mple → benched"
+func (s *Service) summary(ctx context.Context, t *entity.Team, e *entity.RosterEvent) (string, error) {
+	player := e.Player.Name
+	switch e.Type {
+	case entity.EventPlayerAdded:
+		return fmt.Sprintf("%s: %s joined as %s", label(t), player, e.To), nil
+	case entity.EventRoleChanged:
+		return fmt.Sprintf("%s: %s role %s → %s", label(t), player, e.From, e.To), nil
+	case entity.EventPlayerTransferred:
+		joined := e.TeamID != nil && *e.TeamID == t.ID
+		other := e.Transfer.ToTeamID
+		if joined {
+			other = e.Transfer.FromTeamID
+		}
+
+		otherLabel := "free agency"
+		if other != nil {
+			ot := &entity.Team{}
+			err := s.findTeam(ctx, ot, *other)
+			switch {
+			case err == team.ErrNotFound:
+				otherLabel = "a deleted team"
+			case err != nil:
+				return "", err
+			default:
+				otherLabel = label(ot)
+			}
+		}
+		if joined {
+			return fmt.Sprintf("%s: %s ← %s (%s)", label(t), player, otherLabel, e.Transfer.Type), nil
+		}
+		return fmt.Sprintf("%s: %s → %s (%s)", label(t), player, otherLabel, e.Transfer.Type), nil
+	case entity.EventPlayerRetired:
+		return fmt.Sprintf("%s: %s → retired", label(t), player), nil
+	default:
+		return fmt.Sprintf("%s: %s → %s", label(t), player, e.To), nil
+	}
+}
+
+// findTeam finds the team, the deleted team is not found
+func (s *Service) findTeam(ctx context.Context, t *entity.Team, id int) error {
+	err := s.teamStorage.FindByID(ctx, t, id)
+	if err == sql.ErrNoRows {
+		return team.ErrNotFound
+	}
+	return err
+}
+
+// label returns the short name of the team
+func label(t *entity.Team) string {
+	if t.Tag != "" {
+		return t.Tag
+	}
+	return t.Name
+}
+
+func validate(subscription *entity.Subscription) error {
+	verr := &base.ValidationError{}
+
+	subscription.Channel = strings.TrimSpace(subscription.Channel)
+	if subscription.Events == nil {
+		subscription.Events = []string{}
+	}
+	for _, e := range subscription.Events {
+		if !entity.IsEventType(e) {
+			verr.Add("events", fmt.Sprintf("%q is unknown, must be one of %s", e, strings.Join(entity.EventTypes, ", ")))
+		}
+	}
+	return verr.Err()
+}
+
+func NewService(notifier notif.Notifier, subscriptionStorage, teamStorage data.GenericStorage) *Service {
+	return &Service{
+		notifier:            notifier,
+		subscriptionStorage: subscriptionStorage,
+		teamStorage:         teamStorage,
+	}
+}
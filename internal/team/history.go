@@ -0,0 +1,101 @@
+package team
+
+import (
+	"time"
+
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+)
+
+// membership represents the team and the status of a player at a point in time
+type membership struct {
+	teamID *int
+	status string
+}
+
+// membershipAt reconstructs the membership of the player at the date
+// by replaying its transfers, which must be ordered by date.
+// It returns false when there is no record of the player at the date.
+//
+// A loan is an overlapping stint: the player plays for the loan team until the loan ends,
+// then returns to the team it was loaned from.
+// The players without any transfer before the date are considered to be
+// in their initial team since they were created, and the players deleted before the date are not recorded.
+// The status is only derived from the transfers, a player in a team is active unless benched.
+func membershipAt(player *entity.Roster, transfers []*entity.Transfer, at entity.Date) (membership, bool) {
+	endOfDay := at.Add(24 * time.Hour)
+	if player.DeletedAt != nil && !player.DeletedAt.After(at.Time) {
+		return membership{}, false
+	}
+
+	var (
+		m         membership
+		replayed  bool
+		loanFrom  *int
+		loanUntil *entity.Date
+	)
+	for _, t := range transfers {
+		if t.Date.After(at.Time) {
+			break
+		}
+		replayed = true
+
+		switch t.Type {
+		case entity.TransferSigning:
+			m = membership{teamID: t.ToTeamID, status: entity.StatusActive}
+			loanFrom, loanUntil = nil, nil
+		case entity.TransferLoan:
+			m = membership{teamID: t.ToTeamID, status: entity.StatusActive}
+			loanFrom, loanUntil = t.FromTeamID, t.LoanUntil
+		case entity.TransferLoanReturn:
+			m = membership{teamID: t.ToTeamID, status: entity.StatusActive}
+			if t.ToTeamID == nil {
+				m.status = entity.StatusInactive
+			}
+			loanFrom, loanUntil = nil, nil
+		case entity.TransferBenching:
+			m = membership{teamID: t.ToTeamID, status: entity.StatusBenched}
+		case entity.TransferRetirement:
+			m = membership{status: entity.StatusInactive}
+			loanFrom, loanUntil = nil, nil
+		}
+	}
+
+	if replayed {
+		if loanUntil != nil && !loanUntil.After(at.Time) {
+			m = membership{teamID: loanFrom, status: entity.StatusActive}
+		}
+		return m, true
+	}
+
+	if !player.CreatedAt.Before(endOfDay) {
+		return membership{}, false
+	}
+	if len(transfers) == 0 {
+		// the team can only be changed by a transfer, but the current status may be set later
+		if player.TeamID == nil {
+			return membership{status: entity.StatusInactive}, true
+		}
+		return membership{teamID: player.TeamID, status: entity.StatusActive}, true
+	}
+	return membershipBefore(transfers[0]), true
+}
+
+// membershipBefore returns the membership of the player right before its first transfer
+func membershipBefore(first *entity.Transfer) membership {
+	switch {
+	case first.FromTeamID == nil:
+		return membership{status: entity.StatusInactive}
+	case first.Type == entity.TransferSigning && sameTeam(first.FromTeamID, first.ToTeamID):
+		// signing back to the same team means the player was benched
+		return membership{teamID: first.FromTeamID, status: entity.StatusBenched}
+	default:
+		return membership{teamID: first.FromTeamID, status: entity.StatusActive}
+	}
+}
+
+func sameTeam(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,348 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/event"
+	"github.com/aldyaz/csgo-roster/internal/lineup"
+)
+
+// ErrNotFound is returned when the requested team doesn't exist
+var ErrNotFound = &base.NotFoundError{Resource: "team"}
+
+type IService interface {
+	GetTeams(ctx context.Context, page int, limit int) (entity.TeamList, error)
+	GetTeam(ctx context.Context, id int) (*entity.Team, error)
+	GetTeamRoster(ctx context.Context, id int) (*entity.TeamRoster, error)
+	GetTeamRosterAt(ctx context.Context, id int, at entity.Date) (*entity.TeamRoster, error)
+	ValidateLineup(ctx context.Context, id int, changes []*entity.LineupChange) (*entity.LineupCheck, error)
+	ChangeLineup(ctx context.Context, id int, changes []*entity.LineupChange) (*entity.TeamRoster, error)
+	CreateTeam(ctx context.Context, team *entity.Team) error
+	UpdateTeam(ctx context.Context, team *entity.Team) error
+	DeleteTeam(ctx context.Context, id int) error
+}
+
+type Service struct {
+	manager         *data.Manager
+	checker         *lineup.Checker
+	publisher       event.Publisher
+	teamStorage     data.GenericStorage
+	rosterStorage   data.GenericStorage
+	transferStorage data.GenericStorage
+	// historyStorage reads the deleted players too, they're part of the past rosters
+	historyStorage data.GenericStorage
+}
+
+// GetTeams returns the teams of the page along with the pagination meta
+func (s *Service) GetTeams(ctx context.Context, page int, limit int) (entity.TeamList, error) {
+	total, err := s.teamStorage.Count(ctx)
+	if err != nil {
+		return entity.TeamList{}, err
+	}
+
+	teams := []*entity.Team{}
+	err = s.teamStorage.FindAll(ctx, &teams, page, limit)
+	if err != nil {
+		return entity.TeamList{}, err
+	}
+	return entity.TeamList{Data: teams, Meta: entity.NewPageMeta(total, page, limit)}, nil
+}
+
+func (s *Service) GetTeam(ctx context.Context, id int) (*entity.Team, error) {
+	team := &entity.Team{}
+	err := s.teamStorage.FindByID(ctx, team, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// GetTeamRoster returns the current active players, coaches, analysts, substitutes and benched players of the team
+func (s *Service) GetTeamRoster(ctx context.Context, id int) (*entity.TeamRoster, error) {
+	team, err := s.GetTeam(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	players := []*entity.Roster{}
+	err = s.rosterStorage.Where(ctx, &players, `"teamId" = :teamId ORDER BY "id"`, map[string]interface{}{
+		"teamId": id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newTeamRoster(team, players), nil
+}
+
+// GetTeamRosterAt reconstructs the roster the team had at the date from the transfer history.
+// The players deleted since then are included.
+func (s *Service) GetTeamRosterAt(ctx context.Context, id int, at entity.Date) (*entity.TeamRoster, error) {
+	team, err := s.GetTeam(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	args := map[string]interface{}{
+		"teamId": id,
+	}
+	transferred := `SELECT "playerId" FROM "transfers" WHERE "fromTeamId" = :teamId OR "toTeamId" = :teamId`
+
+	candidates := []*entity.Roster{}
+	err = s.historyStorage.Where(ctx, &candidates, `"teamId" = :teamId OR "id" IN (`+transferred+`) ORDER BY "id"`, args)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := []*entity.Transfer{}
+	err = s.transferStorage.Where(ctx, &transfers, `"playerId" IN (`+transferred+`) ORDER BY "date", "id"`, args)
+	if err != nil {
+		return nil, err
+	}
+
+	playerTransfers := map[int][]*entity.Transfer{}
+	for _, t := range transfers {
+		playerTransfers[t.PlayerID] = append(playerTransfers[t.PlayerID], t)
+	}
+
+	players := []*entity.Roster{}
+	for _, c := range candidates {
+		m, ok := membershipAt(c, playerTransfers[c.ID], at)
+		if !ok || m.teamID == nil || *m.teamID != id {
+			continue
+		}
+
+		p := *c
+		p.TeamID = m.teamID
+		p.Status = m.status
+		players = append(players, &p)
+	}
+
+	roster := newTeamRoster(team, players)
+	roster.At = &at
+	return roster, nil
+}
+
+// ValidateLineup checks the proposed lineup changes against the composition rules without saving them
+func (s *Service) ValidateLineup(ctx context.Context, id int, changes []*entity.LineupChange) (*entity.LineupCheck, error) {
+	if _, err := s.GetTeam(ctx, id); err != nil {
+		return nil, err
+	}
+
+	players, _, err := s.applyChanges(ctx, id, changes)
+	if err != nil {
+		return nil, err
+	}
+	return s.checker.Check(ctx, id, players...)
+}
+
+// ChangeLineup saves the lineup changes at once, so the rules are only checked against the final lineup.
+// It's used to swap the players that can't be changed one by one without breaking the rules.
+// The status and the role changes are published in the same transaction.
+func (s *Service) ChangeLineup(ctx context.Context, id int, changes []*entity.LineupChange) (*entity.TeamRoster, error) {
+	if _, err := s.GetTeam(ctx, id); err != nil {
+		return nil, err
+	}
+
+	err := s.manager.RunInTransaction(ctx, func(tctx context.Context) error {
+		players, previous, err := s.applyChanges(tctx, id, changes)
+		if err != nil {
+			return err
+		}
+		if err := s.checker.Enforce(tctx, id, players...); err != nil {
+			return err
+		}
+
+		for i, p := range players {
+			if err := s.rosterStorage.Update(tctx, p); err != nil {
+				return err
+			}
+			if err := event.Publish(tctx, s.publisher, event.Changed(previous[i], p)...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTeamRoster(ctx, id)
+}
+
+// applyChanges returns the team players with the changes applied along with the players before the changes
+func (s *Service) applyChanges(ctx context.Context, id int, changes []*entity.LineupChange) ([]*entity.Roster, []*entity.Roster, error) {
+	if len(changes) == 0 {
+		return nil, nil, base.NewValidationError("changes", "must not be empty")
+	}
+
+	players := []*entity.Roster{}
+	previous := []*entity.Roster{}
+	changed := map[int]bool{}
+	for _, c := range changes {
+		if changed[c.RosterID] {
+			return nil, nil, base.NewValidationError("changes", fmt.Sprintf("has duplicate roster %d", c.RosterID))
+		}
+		changed[c.RosterID] = true
+
+		p := &entity.Roster{}
+		err := s.rosterStorage.FindByID(ctx, p, c.RosterID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, nil, err
+		}
+		if err == sql.ErrNoRows || p.TeamID == nil || *p.TeamID != id {
+			return nil, nil, base.NewValidationError("changes", fmt.Sprintf("roster %d is not in the team", c.RosterID))
+		}
+		before := *p
+		previous = append(previous, &before)
+
+		switch c.Status {
+		case "":
+		case entity.StatusActive, entity.StatusSubstitute:
+			if p.Status != entity.StatusActive && p.Status != entity.StatusSubstitute {
+				return nil, nil, &base.ConflictError{Message: fmt.Sprintf("status of roster %d can only be changed through a transfer", c.RosterID)}
+			}
+			p.Status = c.Status
+		default:
+			return nil, nil, base.NewValidationError("status", "must be one of active, substitute")
+		}
+
+		if c.Role != "" {
+			p.Role = c.Role
+		}
+		if c.SecondaryRoles != nil {
+			p.SecondaryRoles = c.SecondaryRoles
+		}
+		seen := map[entity.Role]bool{p.Role: true}
+		for _, r := range p.SecondaryRoles {
+			if seen[r] {
+				return nil, nil, base.NewValidationError("secondaryRoles", fmt.Sprintf("of roster %d has duplicate role %s", c.RosterID, r))
+			}
+			seen[r] = true
+		}
+
+		players = append(players, p)
+	}
+	return players, previous, nil
+}
+
+func (s *Service) CreateTeam(ctx context.Context, team *entity.Team) error {
+	if err := validate(team); err != nil {
+		return err
+	}
+	return s.teamStorage.Insert(ctx, team)
+}
+
+// UpdateTeam replaces the stored team having the same id
+func (s *Service) UpdateTeam(ctx context.Context, team *entity.Team) error {
+	if err := validate(team); err != nil {
+		return err
+	}
+	err := s.teamStorage.Update(ctx, team)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// DeleteTeam deletes the team, the team still having players is a conflict.
+// The players must be transferred or retired first, so no player is left in the deleted team.
+func (s *Service) DeleteTeam(ctx context.Context, id int) error {
+	return s.manager.RunInTransaction(ctx, func(tctx context.Context) error {
+		// the team row is locked, so the players joining the team wait for the deletion
+		if _, err := s.GetTeam(tctx, id); err != nil {
+			return err
+		}
+
+		players := []*entity.Roster{}
+		err := s.rosterStorage.Where(tctx, &players, `"teamId" = :teamId LIMIT 1`, map[string]interface{}{
+			"teamId": id,
+		})
+		if err != nil {
+			return err
+		}
+		if len(players) > 0 {
+			return &base.ConflictError{Message: "team still has players, transfer or retire them first"}
+		}
+
+		err = s.teamStorage.Delete(tctx, id)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	})
+}
+
+// newTeamRoster groups the team players by their role and status
+func newTeamRoster(team *entity.Team, players []*entity.Roster) *entity.TeamRoster {
+	roster := &entity.TeamRoster{
+		Team:        team,
+		Active:      []*entity.Roster{},
+		Coaches:     []*entity.Roster{},
+		Analysts:    []*entity.Roster{},
+		Substitutes: []*entity.Roster{},
+		Benched:     []*entity.Roster{},
+	}
+
+	for _, p := range players {
+		switch {
+		case p.Status == entity.StatusInactive:
+		case p.Status == entity.StatusBenched:
+			roster.Benched = append(roster.Benched, p)
+		case p.Role == entity.RoleCoach:
+			roster.Coaches = append(roster.Coaches, p)
+		case p.Role == entity.RoleAnalyst:
+			roster.Analysts = append(roster.Analysts, p)
+		case p.Status == entity.StatusSubstitute || p.Role == entity.RoleSubstitute:
+			roster.Substitutes = append(roster.Substitutes, p)
+		default:
+			roster.Active = append(roster.Active, p)
+		}
+	}
+	return roster
+}
+
+func validate(team *entity.Team) error {
+	verr := &base.ValidationError{}
+
+	team.Name = strings.TrimSpace(team.Name)
+	team.Tag = strings.TrimSpace(team.Tag)
+	team.Region = strings.TrimSpace(team.Region)
+	team.LogoURL = strings.TrimSpace(team.LogoURL)
+	if team.Name == "" {
+		verr.Add("name", "is required")
+	}
+	if team.Tag == "" {
+		verr.Add("tag", "is required")
+	}
+
+	if team.LogoURL != "" {
+		u, err := url.ParseRequestURI(team.LogoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			verr.Add("logoUrl", "must be a http(s) url")
+		}
+	}
+	return verr.Err()
+}
+
+func NewService(manager *data.Manager, checker *lineup.Checker, publisher event.Publisher, teamStorage, rosterStorage, transferStorage, historyStorage data.GenericStorage) *Service {
+	return &Service{
+		manager:         manager,
+		checker:         checker,
+		publisher:       publisher,
+		teamStorage:     teamStorage,
+		rosterStorage:   rosterStorage,
+		transferStorage: transferStorage,
+		historyStorage:  historyStorage,
+	}
+}
@@ -0,0 +1,58 @@
+package transfer
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+)
+
+const defaultExpiryInterval = time.Hour
+
+// ExpirerConfig represent the config needed when creating a new loan expirer.
+// The ended loans are looked for every Interval.
+type ExpirerConfig struct {
+	Interval time.Duration
+}
+
+// Expirer returns the loaned players to their teams once their loan ended
+type Expirer struct {
+	service *Service
+	config  ExpirerConfig
+}
+
+// Run expires the ended loans until the context is done
+func (e *Expirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := e.service.ExpireLoans(ctx, entity.NewDate(time.Now()))
+		if err != nil && ctx.Err() == nil {
+			log.Println("Failed to expire the loans: ", err)
+		}
+		if n > 0 {
+			log.Printf("Returned %d loaned players to their teams\n", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// NewExpirer creates a new expirer of the loans of the service.
+// If the interval is not provided, will look for the ended loans every hour.
+func NewExpirer(service *Service, config ExpirerConfig) *Expirer {
+	if config.Interval <= 0 {
+		config.Interval = defaultExpiryInterval
+	}
+
+	return &Expirer{
+		service: service,
+		config:  config,
+	}
+}
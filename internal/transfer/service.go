@@ -0,0 +1,324 @@
+package transfer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/event"
+	"github.com/aldyaz/csgo-roster/internal/lineup"
+)
+
+// ErrPlayerNotFound is returned when the transferred player doesn't exist
+var ErrPlayerNotFound = &base.NotFoundError{Resource: "player"}
+
+type IService interface {
+	GetPlayerTransfers(ctx context.Context, playerID int) (entity.TransferList, error)
+	CreateTransfer(ctx context.Context, transfer *entity.Transfer) error
+}
+
+type Service struct {
+	manager         *data.Manager
+	checker         *lineup.Checker
+	publisher       event.Publisher
+	transferStorage data.GenericStorage
+	rosterStorage   data.GenericStorage
+	teamStorage     data.GenericStorage
+}
+
+// GetPlayerTransfers returns the transfer history of the player, the latest first
+func (s *Service) GetPlayerTransfers(ctx context.Context, playerID int) (entity.TransferList, error) {
+	err := s.rosterStorage.FindByID(ctx, &entity.Roster{}, playerID)
+	if err == sql.ErrNoRows {
+		return entity.TransferList{}, ErrPlayerNotFound
+	}
+	if err != nil {
+		return entity.TransferList{}, err
+	}
+
+	transfers := []*entity.Transfer{}
+	err = s.transferStorage.Where(ctx, &transfers, `"playerId" = :playerId ORDER BY "date" DESC, "id" DESC`, map[string]interface{}{
+		"playerId": playerID,
+	})
+	if err != nil {
+		return entity.TransferList{}, err
+	}
+	return entity.TransferList{Data: transfers}, nil
+}
+
+// CreateTransfer records the transfer and moves the player to the destination team.
+// Both are done in a single transaction so the player's team always matches its latest transfer,
+// the transfer is published in the same transaction.
+// Only the managers of the destination team can sign or loan the player,
+// and only the managers of the current team can bench or retire the player.
+func (s *Service) CreateTransfer(ctx context.Context, transfer *entity.Transfer) error {
+	if err := validate(transfer); err != nil {
+		return err
+	}
+
+	return s.manager.RunInTransaction(ctx, func(tctx context.Context) error {
+		player := &entity.Roster{}
+		err := s.rosterStorage.FindByID(tctx, player, transfer.PlayerID)
+		if err == sql.ErrNoRows {
+			return ErrPlayerNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if transfer.FromTeamID != nil && !sameTeam(transfer.FromTeamID, player.TeamID) {
+			return &base.ConflictError{Message: "fromTeamId does not match the player's current team"}
+		}
+		transfer.FromTeamID = player.TeamID
+		if err := authorize(tctx, transfer, player); err != nil {
+			return err
+		}
+
+		if err := s.validateChronology(tctx, transfer); err != nil {
+			return err
+		}
+		if err := s.apply(tctx, transfer, player); err != nil {
+			return err
+		}
+		// only the players joining a team are checked, a team can't prevent its players from leaving
+		if transfer.Type == entity.TransferSigning || transfer.Type == entity.TransferLoan {
+			if err := s.checker.Enforce(tctx, *player.TeamID, player); err != nil {
+				return err
+			}
+		}
+
+		if err := s.rosterStorage.Update(tctx, player); err != nil {
+			return err
+		}
+		if err := s.transferStorage.Insert(tctx, transfer); err != nil {
+			return err
+		}
+		return event.Publish(tctx, s.publisher, event.Transferred(player, transfer))
+	})
+}
+
+// ExpireLoans returns the loaned players to the teams they're loaned from once their loan ended at the date,
+// it returns the number of returned players.
+// The return is recorded as a loan_return transfer dated on the end of the loan, in the same transaction,
+// so the player's team keeps matching its latest transfer.
+// It's not checked against the lineup rules since the team can't refuse its player back.
+// The players failed to be returned are retried on the next call, they don't block the others.
+func (s *Service) ExpireLoans(ctx context.Context, at entity.Date) (int, error) {
+	// the benching by the loan team doesn't end the loan
+	ended := []*entity.Transfer{}
+	err := s.transferStorage.Where(ctx, &ended, `"type" = :loan AND "loanUntil" <= :at
+		AND "playerId" IN (SELECT "id" FROM "rosters" WHERE "deletedAt" IS NULL)
+		AND NOT EXISTS (
+			SELECT 1 FROM "transfers" AS "later"
+			WHERE "later"."playerId" = "transfers"."playerId" AND "later"."type" <> :benching
+			AND ("later"."date", "later"."id") > ("transfers"."date", "transfers"."id")
+		) ORDER BY "loanUntil", "id"`, map[string]interface{}{
+		"loan":     entity.TransferLoan,
+		"benching": entity.TransferBenching,
+		"at":       at,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	returned := 0
+	for _, loan := range ended {
+		ok, err := s.returnLoan(ctx, loan.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return returned, ctx.Err()
+			}
+			log.Printf("Failed to return the player %d from the loan %d: %v\n", loan.PlayerID, loan.ID, err)
+			continue
+		}
+		if ok {
+			returned++
+		}
+	}
+	return returned, nil
+}
+
+// returnLoan moves the loaned player back to the team it's loaned from.
+// It returns false if the loan is not the player's latest move anymore, e.g. it's returned by another call.
+// The player becomes a free agent if the team it's loaned from was deleted during the loan.
+func (s *Service) returnLoan(ctx context.Context, loanID int) (bool, error) {
+	returned := false
+	err := s.manager.RunInTransaction(ctx, func(tctx context.Context) error {
+		loan := &entity.Transfer{}
+		if err := s.transferStorage.FindByID(tctx, loan, loanID); err != nil {
+			return err
+		}
+		player := &entity.Roster{}
+		err := s.rosterStorage.FindByID(tctx, player, loan.PlayerID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// the player is locked, so the latest move can't change until the return is committed
+		latest := &entity.Transfer{}
+		err = s.transferStorage.Single(tctx, latest, `"playerId" = :playerId AND "type" <> :benching ORDER BY "date" DESC, "id" DESC LIMIT 1`, map[string]interface{}{
+			"playerId": loan.PlayerID,
+			"benching": entity.TransferBenching,
+		})
+		if err != nil {
+			return err
+		}
+		if latest.ID != loan.ID || !sameTeam(player.TeamID, loan.ToTeamID) {
+			return nil
+		}
+
+		back := &entity.Transfer{
+			PlayerID:   player.ID,
+			FromTeamID: player.TeamID,
+			ToTeamID:   loan.FromTeamID,
+			Type:       entity.TransferLoanReturn,
+			Date:       *loan.LoanUntil,
+		}
+		if back.ToTeamID != nil {
+			err := s.teamStorage.FindByID(tctx, &entity.Team{}, *back.ToTeamID)
+			if err == sql.ErrNoRows {
+				back.ToTeamID = nil
+			} else if err != nil {
+				return err
+			}
+		}
+		player.TeamID = back.ToTeamID
+		player.Status = entity.StatusActive
+		if player.TeamID == nil {
+			player.Status = entity.StatusInactive
+		}
+
+		if err := s.rosterStorage.Update(tctx, player); err != nil {
+			return err
+		}
+		if err := s.transferStorage.Insert(tctx, back); err != nil {
+			return err
+		}
+		returned = true
+		return event.Publish(tctx, s.publisher, event.Transferred(player, back))
+	})
+	return returned, err
+}
+
+// apply moves the player according to the transfer type
+func (s *Service) apply(ctx context.Context, transfer *entity.Transfer, player *entity.Roster) error {
+	switch transfer.Type {
+	case entity.TransferSigning, entity.TransferLoan:
+		if transfer.ToTeamID == nil {
+			return base.NewValidationError("toTeamId", "is required")
+		}
+		if sameTeam(transfer.FromTeamID, transfer.ToTeamID) && player.Status != entity.StatusBenched {
+			return base.NewValidationError("toTeamId", "must be different from the player's current team")
+		}
+		err := s.teamStorage.FindByID(ctx, &entity.Team{}, *transfer.ToTeamID)
+		if err == sql.ErrNoRows {
+			return base.NewValidationError("toTeamId", "does not exist")
+		}
+		if err != nil {
+			return err
+		}
+		player.TeamID = transfer.ToTeamID
+		player.Status = entity.StatusActive
+
+	case entity.TransferBenching:
+		if player.TeamID == nil {
+			return &base.ConflictError{Message: "player is not in any team"}
+		}
+		if player.Status == entity.StatusBenched {
+			return &base.ConflictError{Message: "player is already benched"}
+		}
+		transfer.ToTeamID = player.TeamID
+		player.Status = entity.StatusBenched
+
+	case entity.TransferRetirement:
+		if player.Status == entity.StatusInactive && player.TeamID == nil {
+			return &base.ConflictError{Message: "player is already retired"}
+		}
+		transfer.ToTeamID = nil
+		player.TeamID = nil
+		player.Status = entity.StatusInactive
+	}
+	return nil
+}
+
+// authorize checks the current user manages the team deciding the transfer
+func authorize(ctx context.Context, transfer *entity.Transfer, player *entity.Roster) error {
+	teamID := player.TeamID
+	if transfer.Type == entity.TransferSigning || transfer.Type == entity.TransferLoan {
+		teamID = transfer.ToTeamID
+	}
+	if teamID == nil {
+		return base.AuthorizeManager(ctx)
+	}
+	return base.AuthorizeTeam(ctx, *teamID)
+}
+
+// validateChronology makes sure the transfers are recorded in the order of their dates
+func (s *Service) validateChronology(ctx context.Context, transfer *entity.Transfer) error {
+	later := []*entity.Transfer{}
+	err := s.transferStorage.Where(ctx, &later, `"playerId" = :playerId AND "date" > :date LIMIT 1`, map[string]interface{}{
+		"playerId": transfer.PlayerID,
+		"date":     transfer.Date,
+	})
+	if err != nil {
+		return err
+	}
+	if len(later) > 0 {
+		return &base.ConflictError{Message: fmt.Sprintf("date must not be before the latest transfer on %s", later[0].Date)}
+	}
+	return nil
+}
+
+func validate(transfer *entity.Transfer) error {
+	verr := &base.ValidationError{}
+
+	switch transfer.Type {
+	case entity.TransferSigning, entity.TransferBenching, entity.TransferLoan, entity.TransferRetirement:
+	default:
+		verr.Add("type", "must be one of signing, benching, loan, retirement")
+	}
+
+	if transfer.Date.IsZero() {
+		verr.Add("date", "is required")
+	} else if transfer.Date.After(time.Now().UTC()) {
+		// the player's team is changed right away, so the transfers can't be scheduled
+		verr.Add("date", "must not be in the future")
+	}
+	if transfer.Fee != nil && *transfer.Fee < 0 {
+		verr.Add("fee", "must not be negative")
+	}
+	if transfer.LoanUntil != nil {
+		if transfer.Type != entity.TransferLoan {
+			verr.Add("loanUntil", "is only allowed for loan")
+		} else if !transfer.LoanUntil.After(transfer.Date.Time) {
+			verr.Add("loanUntil", "must be after the transfer date")
+		}
+	}
+	return verr.Err()
+}
+
+func sameTeam(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func NewService(manager *data.Manager, checker *lineup.Checker, publisher event.Publisher, transferStorage, rosterStorage, teamStorage data.GenericStorage) *Service {
+	return &Service{
+		manager:         manager,
+		checker:         checker,
+		publisher:       publisher,
+		transferStorage: transferStorage,
+		rosterStorage:   rosterStorage,
+		teamStorage:     teamStorage,
+	}
+}
@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/notif"
+)
+
+// The headers of the delivery request along with the notif signature headers
+const (
+	EventHeader    = "X-Webhook-Event"
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+const (
+	defaultDispatchInterval = 5 * time.Second
+	defaultDispatchBatch    = 20
+	defaultMaxAttempts      = 8
+	defaultRetryDelay       = 30 * time.Second
+	defaultMaxRetryDelay    = 6 * time.Hour
+	defaultDeliveryTimeout  = 10 * time.Second
+	// claimLease is how long the claimed deliveries are hidden from the other dispatchers,
+	// they're attempted again if the dispatcher crashes before recording the attempt
+	claimLease = 5 * time.Minute
+	// maxErrorBody is the maximum length of the response body kept in the delivery log
+	maxErrorBody = 512
+)
+
+// DispatcherConfig represent the config needed when creating a new dispatcher.
+//
+// The pending deliveries are polled every Interval, up to BatchSize at once.
+// The failed attempt is retried after RetryDelay that is doubled on each attempt up to MaxRetryDelay,
+// the delivery is failed after MaxAttempts.
+type DispatcherConfig struct {
+	Interval      time.Duration
+	BatchSize     int
+	MaxAttempts   int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	HTTPClient    *http.Client
+}
+
+// Dispatcher delivers the pending deliveries to the webhooks.
+// The deliveries are stored, so they survive the restarts
+// and several dispatchers can run against the same database.
+type Dispatcher struct {
+	manager         *data.Manager
+	webhookStorage  data.GenericStorage
+	deliveryStorage data.GenericStorage
+	config          DispatcherConfig
+}
+
+// Run dispatches the pending deliveries until the context is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			log.Println("Failed to dispatch webhook deliveries: ", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch attempts the pending deliveries that are due, it returns the number of recorded attempts.
+// The deliveries failed to be attempted are postponed, they don't block the others.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	for _, delivery := range deliveries {
+		err := d.attempt(ctx, delivery)
+		if err != nil {
+			if ctx.Err() != nil {
+				return attempted, ctx.Err()
+			}
+			log.Printf("Failed to attempt the webhook delivery %d: %v\n", delivery.ID, err)
+			err = d.postpone(ctx, delivery, err)
+		}
+		if err != nil {
+			// the delivery is attempted again once the claim lease expires
+			log.Printf("Failed to record the webhook delivery %d: %v\n", delivery.ID, err)
+			continue
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+// claim locks the due deliveries and postpones them by the claim lease,
+// so the other dispatchers don't attempt them at the same time
+func (d *Dispatcher) claim(ctx context.Context) ([]*entity.Delivery, error) {
+	deliveries := []*entity.Delivery{}
+	err := d.manager.RunInTransaction(ctx, func(tctx context.Context) error {
+		now := time.Now().UTC()
+		err := d.deliveryStorage.Where(tctx, &deliveries, `"status" = :status AND "nextAttemptAt" <= :now ORDER BY "nextAttemptAt" LIMIT :limit`, map[string]interface{}{
+			"status": entity.DeliveryPending,
+			"now":    now,
+			"limit":  d.config.BatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		lease := now.Add(claimLease)
+		for _, delivery := range deliveries {
+			delivery.NextAttemptAt = &lease
+			if err := d.deliveryStorage.Update(tctx, delivery); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// attempt posts the delivery to its webhook and records the result
+func (d *Dispatcher) attempt(ctx context.Context, delivery *entity.Delivery) error {
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = nil
+
+	webhook := &entity.Webhook{}
+	err := d.webhookStorage.FindByID(ctx, webhook, delivery.WebhookID)
+	switch {
+	case err == sql.ErrNoRows:
+		delivery.Status = entity.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = "webhook is deleted"
+		return d.deliveryStorage.Update(ctx, delivery)
+	case err != nil:
+		return err
+	}
+
+	status, err := d.post(ctx, webhook, delivery)
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+	switch {
+	case err == nil:
+		delivery.Status = entity.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+	case delivery.Attempts >= d.config.MaxAttempts:
+		delivery.Status = entity.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = err.Error()
+	default:
+		next := now.Add(d.backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.LastError = err.Error()
+	}
+	return d.deliveryStorage.Update(ctx, delivery)
+}
+
+// postpone records the attempt failed before its result could be recorded and schedules the next one,
+// the delivery is failed if it was the last attempt
+func (d *Dispatcher) postpone(ctx context.Context, delivery *entity.Delivery, cause error) error {
+	delivery.LastError = cause.Error()
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = entity.DeliveryFailed
+		delivery.NextAttemptAt = nil
+	} else {
+		next := time.Now().UTC().Add(d.backoff(delivery.Attempts))
+		delivery.Status = entity.DeliveryPending
+		delivery.NextAttemptAt = &next
+	}
+	return d.deliveryStorage.Update(ctx, delivery)
+}
+
+// post sends the signed payload to the webhook url, it returns the response status if any
+func (d *Dispatcher) post(ctx context.Context, webhook *entity.Webhook, delivery *entity.Delivery) (int, error) {
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(notif.SignatureTimestampHeader, timestamp)
+	req.Header.Set(notif.SignatureHeader, notif.Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	res, err := d.config.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+		return res.StatusCode, fmt.Errorf("webhook responded with status %d: %s", res.StatusCode, body)
+	}
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	return res.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.RetryDelay
+	for i := 1; i < attempts && delay < d.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxRetryDelay {
+		delay = d.config.MaxRetryDelay
+	}
+	return delay
+}
+
+// NewDispatcher creates a new dispatcher of the deliveries.
+// If the interval is not provided, will poll the deliveries every 5secs.
+// If the retry is not configured, will attempt up to 8 times from 30secs to 6hrs apart.
+// If the http client is not provided, will use the default http client with timeout 10secs
+func NewDispatcher(manager *data.Manager, webhookStorage, deliveryStorage data.GenericStorage, config DispatcherConfig) *Dispatcher {
+	if config.Interval <= 0 {
+		config.Interval = defaultDispatchInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultDispatchBatch
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultRetryDelay
+	}
+	if config.MaxRetryDelay <= 0 {
+		config.MaxRetryDelay = defaultMaxRetryDelay
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: defaultDeliveryTimeout}
+	}
+
+	return &Dispatcher{
+		manager:         manager,
+		webhookStorage:  webhookStorage,
+		deliveryStorage: deliveryStorage,
+		config:          config,
+	}
+}
@@ -0,0 +1,428 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+	"github.com/aldyaz/csgo-roster/internal/notif"
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeStorage is the in-memory storage of the webhooks and deliveries,
+// the storage methods the dispatcher doesn't use panic
+type fakeStorage struct {
+	data.GenericStorage
+
+	mu         sync.Mutex
+	webhooks   map[int]*entity.Webhook
+	deliveries map[int]*entity.Delivery
+	findErr    error
+	// attemptErr is the error of recording the attempts of the delivery
+	attemptErr map[int]error
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{
+		webhooks:   map[int]*entity.Webhook{},
+		deliveries: map[int]*entity.Delivery{},
+		attemptErr: map[int]error{},
+	}
+}
+
+func (s *fakeStorage) FindByID(ctx context.Context, elem interface{}, id interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findErr != nil {
+		return s.findErr
+	}
+	webhook, ok := s.webhooks[id.(int)]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	*elem.(*entity.Webhook) = *webhook
+	return nil
+}
+
+// Where returns the pending deliveries due at :now, ordered by id rather than nextAttemptAt
+func (s *fakeStorage) Where(ctx context.Context, elems interface{}, where string, arg interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	args := arg.(map[string]interface{})
+	now := args["now"].(time.Time)
+	deliveries := elems.(*[]*entity.Delivery)
+	for id := 1; id <= len(s.deliveries) && len(*deliveries) < args["limit"].(int); id++ {
+		d, ok := s.deliveries[id]
+		if ok && d.Status == args["status"] && !d.NextAttemptAt.After(now) {
+			copied := *d
+			*deliveries = append(*deliveries, &copied)
+		}
+	}
+	return nil
+}
+
+func (s *fakeStorage) Update(ctx context.Context, elem interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery := *elem.(*entity.Delivery)
+	if err := s.attemptErr[delivery.ID]; err != nil && delivery.Attempts > 0 {
+		return err
+	}
+	s.deliveries[delivery.ID] = &delivery
+	return nil
+}
+
+func (s *fakeStorage) delivery(id int) *entity.Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deliveries[id]
+}
+
+// fakeDriver is the database driver whose transactions do nothing,
+// so the manager can run the transactions of the fake storage
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func init() {
+	sql.Register("webhook-fake", fakeDriver{})
+}
+
+func newFakeManager(t *testing.T) *data.Manager {
+	t.Helper()
+	db, err := sqlx.Open("webhook-fake", "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() error = %v", err)
+	}
+	return data.NewManager(db)
+}
+
+func newTestDispatcher(t *testing.T, storage *fakeStorage) *Dispatcher {
+	return NewDispatcher(newFakeManager(t), storage, storage, DispatcherConfig{
+		MaxAttempts:   3,
+		RetryDelay:    time.Minute,
+		MaxRetryDelay: time.Hour,
+	})
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	d := NewDispatcher(nil, nil, nil, DispatcherConfig{})
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: 30 * time.Second},
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 8, want: 64 * time.Minute},
+		{attempts: 10, want: 256 * time.Minute},
+		{attempts: 11, want: 6 * time.Hour},
+		{attempts: 100, want: 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempts), func(t *testing.T) {
+			if got := d.backoff(tt.attempts); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+
+	capped := NewDispatcher(nil, nil, nil, DispatcherConfig{RetryDelay: time.Hour, MaxRetryDelay: time.Minute})
+	if got := capped.backoff(1); got != time.Minute {
+		t.Errorf("backoff(1) = %v, want the retry delay capped to %v", got, time.Minute)
+	}
+}
+
+func TestDispatcherAttempt(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		attempts  int
+		deleted   bool
+		closed    bool
+		want      string
+		wantNext  time.Duration
+		wantCode  int
+		wantError string
+	}{
+		{
+			name:     "succeeded",
+			status:   http.StatusNoContent,
+			want:     entity.DeliverySucceeded,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:      "rescheduled",
+			status:    http.StatusInternalServerError,
+			attempts:  1,
+			want:      entity.DeliveryPending,
+			wantNext:  2 * time.Minute,
+			wantCode:  http.StatusInternalServerError,
+			wantError: "status 500: partner is down",
+		},
+		{
+			name:      "rescheduled on 4xx",
+			status:    http.StatusGone,
+			want:      entity.DeliveryPending,
+			wantNext:  time.Minute,
+			wantCode:  http.StatusGone,
+			wantError: "status 410",
+		},
+		{
+			name:      "rescheduled without response",
+			closed:    true,
+			want:      entity.DeliveryPending,
+			wantNext:  time.Minute,
+			wantError: "connect",
+		},
+		{
+			name:      "failed on the last attempt",
+			status:    http.StatusBadGateway,
+			attempts:  2,
+			want:      entity.DeliveryFailed,
+			wantCode:  http.StatusBadGateway,
+			wantError: "status 502",
+		},
+		{
+			name:      "webhook is deleted",
+			deleted:   true,
+			want:      entity.DeliveryFailed,
+			wantError: "webhook is deleted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				received = req
+				body, _ = ioutil.ReadAll(req.Body)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("partner is down"))
+			}))
+			defer server.Close()
+			if tt.closed {
+				server.Close()
+			}
+
+			storage := newFakeStorage()
+			if !tt.deleted {
+				storage.webhooks[7] = &entity.Webhook{ID: 7, URL: server.URL, Secret: "whsec"}
+			}
+			status := http.StatusTeapot
+			storage.deliveries[1] = &entity.Delivery{
+				ID:             1,
+				WebhookID:      7,
+				EventType:      "roster.created",
+				Payload:        entity.JSON(`{"rosterId":1}`),
+				Status:         entity.DeliveryPending,
+				Attempts:       tt.attempts,
+				ResponseStatus: &status,
+				LastError:      "previous error",
+			}
+			d := newTestDispatcher(t, storage)
+
+			before := time.Now().UTC()
+			if err := d.attempt(context.Background(), storage.delivery(1)); err != nil {
+				t.Fatalf("attempt() error = %v", err)
+			}
+			after := time.Now().UTC()
+
+			got := storage.delivery(1)
+			if got.Status != tt.want {
+				t.Errorf("status = %q, want %q", got.Status, tt.want)
+			}
+			if got.Attempts != tt.attempts+1 {
+				t.Errorf("attempts = %d, want %d", got.Attempts, tt.attempts+1)
+			}
+			if got.LastAttemptAt == nil || got.LastAttemptAt.Before(before) || got.LastAttemptAt.After(after) {
+				t.Errorf("lastAttemptAt = %v, want the attempt time", got.LastAttemptAt)
+			}
+			switch {
+			case tt.wantNext == 0 && got.NextAttemptAt != nil:
+				t.Errorf("nextAttemptAt = %v, want none once the delivery is done", got.NextAttemptAt)
+			case tt.wantNext != 0 && (got.NextAttemptAt == nil || got.NextAttemptAt.Before(before.Add(tt.wantNext)) || got.NextAttemptAt.After(after.Add(tt.wantNext))):
+				t.Errorf("nextAttemptAt = %v, want %v after the attempt", got.NextAttemptAt, tt.wantNext)
+			}
+			switch {
+			case tt.wantCode == 0 && got.ResponseStatus != nil:
+				t.Errorf("responseStatus = %d, want none", *got.ResponseStatus)
+			case tt.wantCode != 0 && (got.ResponseStatus == nil || *got.ResponseStatus != tt.wantCode):
+				t.Errorf("responseStatus = %v, want %d", got.ResponseStatus, tt.wantCode)
+			}
+			if tt.wantError == "" && got.LastError != "" || !strings.Contains(got.LastError, tt.wantError) {
+				t.Errorf("lastError = %q, want %q", got.LastError, tt.wantError)
+			}
+
+			if tt.deleted || tt.closed {
+				return
+			}
+			if received == nil {
+				t.Fatal("the webhook didn't receive the delivery")
+			}
+			if string(body) != `{"rosterId":1}` {
+				t.Errorf("body = %s, want the payload", body)
+			}
+			if got := received.Header.Get(EventHeader); got != "roster.created" {
+				t.Errorf("event header = %q, want %q", got, "roster.created")
+			}
+			if got := received.Header.Get(DeliveryHeader); got != "1" {
+				t.Errorf("delivery header = %q, want %q", got, "1")
+			}
+			timestamp := received.Header.Get(notif.SignatureTimestampHeader)
+			if got, want := received.Header.Get(notif.SignatureHeader), notif.Sign("whsec", timestamp, body); got != want {
+				t.Errorf("signature = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDispatcherAttemptStorageError(t *testing.T) {
+	storage := newFakeStorage()
+	storage.findErr = errors.New("connection reset")
+	storage.deliveries[1] = &entity.Delivery{ID: 1, WebhookID: 7, Status: entity.DeliveryPending}
+	d := newTestDispatcher(t, storage)
+
+	if err := d.attempt(context.Background(), storage.delivery(1)); err != storage.findErr {
+		t.Errorf("attempt() error = %v, want %v", err, storage.findErr)
+	}
+}
+
+func TestDispatcherPostpone(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     string
+		wantNext time.Duration
+	}{
+		{name: "rescheduled", attempts: 1, want: entity.DeliveryPending, wantNext: time.Minute},
+		{name: "backed off", attempts: 2, want: entity.DeliveryPending, wantNext: 2 * time.Minute},
+		{name: "failed on the last attempt", attempts: 3, want: entity.DeliveryFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newFakeStorage()
+			d := newTestDispatcher(t, storage)
+			delivery := &entity.Delivery{ID: 1, Status: entity.DeliveryPending, Attempts: tt.attempts}
+
+			before := time.Now().UTC()
+			if err := d.postpone(context.Background(), delivery, errors.New("connection reset")); err != nil {
+				t.Fatalf("postpone() error = %v", err)
+			}
+
+			got := storage.delivery(1)
+			if got.Status != tt.want {
+				t.Errorf("status = %q, want %q", got.Status, tt.want)
+			}
+			if got.LastError != "connection reset" {
+				t.Errorf("lastError = %q, want the cause", got.LastError)
+			}
+			if tt.wantNext == 0 && got.NextAttemptAt != nil {
+				t.Errorf("nextAttemptAt = %v, want none once failed", got.NextAttemptAt)
+			}
+			if tt.wantNext != 0 && (got.NextAttemptAt == nil || got.NextAttemptAt.Before(before.Add(tt.wantNext))) {
+				t.Errorf("nextAttemptAt = %v, want %v later", got.NextAttemptAt, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestDispatcherClaim(t *testing.T) {
+	storage := newFakeStorage()
+	past := time.Now().UTC().Add(-time.Minute)
+	future := time.Now().UTC().Add(time.Hour)
+	storage.deliveries[1] = &entity.Delivery{ID: 1, Status: entity.DeliveryPending, NextAttemptAt: &past}
+	storage.deliveries[2] = &entity.Delivery{ID: 2, Status: entity.DeliveryPending, NextAttemptAt: &future}
+	storage.deliveries[3] = &entity.Delivery{ID: 3, Status: entity.DeliveryPending, NextAttemptAt: &past}
+	d := newTestDispatcher(t, storage)
+
+	before := time.Now().UTC()
+	deliveries, err := d.claim(context.Background())
+	if err != nil {
+		t.Fatalf("claim() error = %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].ID != 1 || deliveries[1].ID != 3 {
+		t.Fatalf("claimed %+v, want the due deliveries 1 and 3", deliveries)
+	}
+	for _, id := range []int{1, 3} {
+		next := storage.delivery(id).NextAttemptAt
+		if next == nil || next.Before(before.Add(claimLease)) {
+			t.Errorf("delivery %d nextAttemptAt = %v, want it leased for %v", id, next, claimLease)
+		}
+	}
+	if next := storage.delivery(2).NextAttemptAt; !next.Equal(future) {
+		t.Errorf("delivery 2 nextAttemptAt = %v, want it unchanged", next)
+	}
+
+	// the leased deliveries aren't claimed again until the lease expires
+	deliveries, err = d.claim(context.Background())
+	if err != nil {
+		t.Fatalf("claim() error = %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Errorf("claimed %d deliveries again, want none during the lease", len(deliveries))
+	}
+}
+
+func TestDispatcherDispatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	storage := newFakeStorage()
+	past := time.Now().UTC().Add(-time.Minute)
+	storage.webhooks[7] = &entity.Webhook{ID: 7, URL: server.URL}
+	storage.deliveries[1] = &entity.Delivery{ID: 1, WebhookID: 7, Status: entity.DeliveryPending, NextAttemptAt: &past}
+	storage.deliveries[2] = &entity.Delivery{ID: 2, WebhookID: 8, Status: entity.DeliveryPending, NextAttemptAt: &past}
+	storage.deliveries[3] = &entity.Delivery{ID: 3, WebhookID: 7, Status: entity.DeliveryPending, NextAttemptAt: &past}
+	d := newTestDispatcher(t, storage)
+
+	// the attempt of the delivery 3 fails to be recorded,
+	// so it's left under the claim lease to be attempted again once the lease expires
+	storage.attemptErr[3] = errors.New("connection reset")
+
+	n, err := d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Dispatch() = %d, want the 2 recorded attempts", n)
+	}
+	if got := storage.delivery(1).Status; got != entity.DeliverySucceeded {
+		t.Errorf("delivery 1 status = %q, want %q", got, entity.DeliverySucceeded)
+	}
+	if got := storage.delivery(2); got.Status != entity.DeliveryFailed || got.LastError != "webhook is deleted" {
+		t.Errorf("delivery 2 = %q %q, want failed as the webhook is deleted", got.Status, got.LastError)
+	}
+	if got := storage.delivery(3); got.Status != entity.DeliveryPending || got.Attempts != 0 || got.NextAttemptAt.Before(time.Now().UTC().Add(claimLease-time.Minute)) {
+		t.Errorf("delivery 3 = %+v, want it left pending under the claim lease", got)
+	}
+}
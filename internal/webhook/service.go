@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aldyaz/csgo-roster/internal/base"
+	"github.com/aldyaz/csgo-roster/internal/data"
+	"github.com/aldyaz/csgo-roster/internal/data/entity"
+)
+
+// ErrNotFound is returned when the requested webhook doesn't exist
+var ErrNotFound = &base.NotFoundError{Resource: "webhook"}
+
+// ErrDeliveryNotFound is returned when the requested delivery doesn't exist
+var ErrDeliveryNotFound = &base.NotFoundError{Resource: "delivery"}
+
+type IService interface {
+	GetWebhooks(ctx context.Context) (entity.WebhookList, error)
+	GetWebhook(ctx context.Context, id int) (*entity.Webhook, error)
+	CreateWebhook(ctx context.Context, webhook *entity.Webhook) error
+	DeleteWebhook(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, webhookID int, limit int) (entity.DeliveryList, error)
+	Redeliver(ctx context.Context, webhookID int, deliveryID int) (*entity.Delivery, error)
+}
+
+// Service manages the webhooks and queues the roster events to be delivered to them
+type Service struct {
+	webhookStorage  data.GenericStorage
+	deliveryStorage data.GenericStorage
+}
+
+// GetWebhooks returns all webhooks without their secrets
+func (s *Service) GetWebhooks(ctx context.Context) (entity.WebhookList, error) {
+	webhooks, err := s.findWebhooks(ctx)
+	if err != nil {
+		return entity.WebhookList{}, err
+	}
+	for _, w := range webhooks {
+		w.Secret = ""
+	}
+	return entity.WebhookList{Data: webhooks}, nil
+}
+
+// GetWebhook returns the webhook without its secret
+func (s *Service) GetWebhook(ctx context.Context, id int) (*entity.Webhook, error) {
+	webhook, err := s.findWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// CreateWebhook saves the new webhook, a random secret is generated if it's not provided.
+// The secret is kept in the webhook, so it can be shown this once.
+func (s *Service) CreateWebhook(ctx context.Context, webhook *entity.Webhook) error {
+	if err := validate(webhook); err != nil {
+		return err
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = newSecret()
+	}
+	return s.webhookStorage.Insert(ctx, webhook)
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, id int) error {
+	err := s.webhookStorage.Delete(ctx, id)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// GetDeliveries returns the latest deliveries of the webhook, the latest first
+func (s *Service) GetDeliveries(ctx context.Context, webhookID int, limit int) (entity.DeliveryList, error) {
+	if _, err := s.findWebhook(ctx, webhookID); err != nil {
+		return entity.DeliveryList{}, err
+	}
+
+	deliveries := []*entity.Delivery{}
+	err := s.deliveryStorage.Where(ctx, &deliveries, `"webhookId" = :webhookId ORDER BY "id" DESC LIMIT :limit`, map[string]interface{}{
+		"webhookId": webhookID,
+		"limit":     limit,
+	})
+	if err != nil {
+		return entity.DeliveryList{}, err
+	}
+	return entity.DeliveryList{Data: deliveries}, nil
+}
+
+// Redeliver queues the event of the delivery to be delivered again.
+// It's a new delivery, so the log of the previous attempts is kept.
+func (s *Service) Redeliver(ctx context.Context, webhookID int, deliveryID int) (*entity.Delivery, error) {
+	if _, err := s.findWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	previous := &entity.Delivery{}
+	err := s.deliveryStorage.FindByID(ctx, previous, deliveryID)
+	if err == sql.ErrNoRows || (err == nil && previous.WebhookID != webhookID) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := newDelivery(webhookID, previous.EventID, previous.EventType, previous.Payload)
+	if err := s.deliveryStorage.Insert(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Publish queues the event to be delivered to the webhooks accepting it
+func (s *Service) Publish(ctx context.Context, e *entity.RosterEvent) error {
+	webhooks, err := s.findWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	for _, w := range webhooks {
+		if !w.Accepts(e.Type) {
+			continue
+		}
+		if err := s.deliveryStorage.Insert(ctx, newDelivery(w.ID, e.ID, e.Type, payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) findWebhooks(ctx context.Context) ([]*entity.Webhook, error) {
+	webhooks := []*entity.Webhook{}
+	err := s.webhookStorage.Where(ctx, &webhooks, `TRUE ORDER BY "id"`, map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (s *Service) findWebhook(ctx context.Context, id int) (*entity.Webhook, error) {
+	webhook := &entity.Webhook{}
+	err := s.webhookStorage.FindByID(ctx, webhook, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// newDelivery returns the pending delivery to be attempted as soon as possible
+func newDelivery(webhookID int, eventID, eventType string, payload []byte) *entity.Delivery {
+	now := time.Now().UTC()
+	return &entity.Delivery{
+		WebhookID:     webhookID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        entity.DeliveryPending,
+		NextAttemptAt: &now,
+	}
+}
+
+func newSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func validate(webhook *entity.Webhook) error {
+	verr := &base.ValidationError{}
+
+	webhook.URL = strings.TrimSpace(webhook.URL)
+	if webhook.URL == "" {
+		verr.Add("url", "is required")
+	} else if u, err := url.ParseRequestURI(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add("url", "must be a http(s) url")
+	}
+
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	for _, e := range webhook.Events {
+		if !entity.IsEventType(e) {
+			verr.Add("events", fmt.Sprintf("%q is unknown, must be one of %s", e, strings.Join(entity.EventTypes, ", ")))
+		}
+	}
+	return verr.Err()
+}
+
+func NewService(webhookStorage, deliveryStorage data.GenericStorage) *Service {
+	return &Service{
+		webhookStorage:  webhookStorage,
+		deliveryStorage: deliveryStorage,
+	}
+}
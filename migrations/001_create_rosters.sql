@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "rosters" (
+    "id" SERIAL PRIMARY KEY,
+    "name" TEXT NOT NULL,
+    "role" TEXT NOT NULL,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "deletedAt" TIMESTAMP
+);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "teams" (
+    "id" SERIAL PRIMARY KEY,
+    "name" TEXT NOT NULL,
+    "tag" TEXT NOT NULL,
+    "region" TEXT NOT NULL DEFAULT '',
+    "logoUrl" TEXT NOT NULL DEFAULT '',
+    "founded" DATE,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "deletedAt" TIMESTAMP
+);
+
+ALTER TABLE "rosters" ADD COLUMN IF NOT EXISTS "teamId" INTEGER REFERENCES "teams" ("id");
+ALTER TABLE "rosters" ADD COLUMN IF NOT EXISTS "status" TEXT NOT NULL DEFAULT 'active';
+
+CREATE INDEX IF NOT EXISTS "rosters_teamId_idx" ON "rosters" ("teamId");
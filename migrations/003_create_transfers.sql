@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "transfers" (
+    "id" SERIAL PRIMARY KEY,
+    "playerId" INTEGER NOT NULL REFERENCES "rosters" ("id"),
+    "fromTeamId" INTEGER REFERENCES "teams" ("id"),
+    "toTeamId" INTEGER REFERENCES "teams" ("id"),
+    "type" TEXT NOT NULL,
+    "date" DATE NOT NULL,
+    "fee" BIGINT,
+    "loanUntil" DATE,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "transfers_playerId_date_idx" ON "transfers" ("playerId", "date");
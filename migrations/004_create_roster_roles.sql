@@ -0,0 +1,21 @@
+-- the legacy free-text roles are mapped to the role enum, the unknown ones fall back to rifler
+UPDATE "rosters" SET "role" = CASE
+    WHEN lower(trim("role")) IN ('igl', 'awp', 'entry', 'support', 'lurker', 'rifler', 'coach', 'analyst', 'substitute') THEN lower(trim("role"))
+    WHEN lower(trim("role")) IN ('in-game leader', 'in game leader') THEN 'igl'
+    WHEN lower(trim("role")) IN ('awper', 'sniper') THEN 'awp'
+    WHEN lower(trim("role")) IN ('entry fragger', 'entry-fragger', 'entryfragger') THEN 'entry'
+    WHEN lower(trim("role")) = 'sub' THEN 'substitute'
+    ELSE 'rifler'
+END
+WHERE "role" NOT IN ('igl', 'awp', 'entry', 'support', 'lurker', 'rifler', 'coach', 'analyst', 'substitute');
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'rosters_role_check') THEN
+        ALTER TABLE "rosters" ADD CONSTRAINT "rosters_role_check"
+            CHECK ("role" IN ('igl', 'awp', 'entry', 'support', 'lurker', 'rifler', 'coach', 'analyst', 'substitute'));
+    END IF;
+END
+$$;
+
+ALTER TABLE "rosters" ADD COLUMN IF NOT EXISTS "secondaryRoles" TEXT[] NOT NULL DEFAULT '{}';
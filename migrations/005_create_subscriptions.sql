@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "subscriptions" (
+    "id" SERIAL PRIMARY KEY,
+    "teamId" INTEGER NOT NULL REFERENCES "teams" ("id") ON DELETE CASCADE,
+    "channel" TEXT NOT NULL DEFAULT '',
+    "events" TEXT[] NOT NULL DEFAULT '{}',
+    "createdAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "deletedAt" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "subscriptions_teamId_idx" ON "subscriptions" ("teamId");
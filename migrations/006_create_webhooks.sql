@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS "webhooks" (
+    "id" SERIAL PRIMARY KEY,
+    "url" TEXT NOT NULL,
+    "secret" TEXT NOT NULL,
+    "events" TEXT[] NOT NULL DEFAULT '{}',
+    "createdAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "deletedAt" TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS "deliveries" (
+    "id" SERIAL PRIMARY KEY,
+    "webhookId" INTEGER NOT NULL REFERENCES "webhooks" ("id"),
+    "eventId" TEXT NOT NULL,
+    "eventType" TEXT NOT NULL,
+    "payload" JSONB NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'succeeded', 'failed')),
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "nextAttemptAt" TIMESTAMP,
+    "lastAttemptAt" TIMESTAMP,
+    "responseStatus" INTEGER,
+    "lastError" TEXT NOT NULL DEFAULT '',
+    "createdAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "deliveries_webhookId_idx" ON "deliveries" ("webhookId", "id");
+CREATE INDEX IF NOT EXISTS "deliveries_pending_idx" ON "deliveries" ("nextAttemptAt") WHERE "status" = 'pending';
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "outbox" (
+    "id" SERIAL PRIMARY KEY,
+    "eventId" TEXT NOT NULL,
+    "eventType" TEXT NOT NULL,
+    "subscriber" TEXT NOT NULL,
+    "payload" JSONB NOT NULL,
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "nextAttemptAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "lastError" TEXT NOT NULL DEFAULT '',
+    "publishedAt" TIMESTAMP,
+    "failedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE ("eventId", "subscriber")
+);
+
+CREATE INDEX IF NOT EXISTS "outbox_pending_idx" ON "outbox" ("subscriber", "nextAttemptAt", "id") WHERE "publishedAt" IS NULL AND "failedAt" IS NULL;
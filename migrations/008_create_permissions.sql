@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "permissions" (
+    "id" SERIAL PRIMARY KEY,
+    "userId" INTEGER NOT NULL,
+    "role" TEXT NOT NULL CHECK ("role" IN ('viewer', 'team_manager', 'admin')),
+    "teamId" INTEGER REFERENCES "teams" ("id") ON DELETE CASCADE,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "deletedAt" TIMESTAMP,
+    CHECK (("role" = 'team_manager') = ("teamId" IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS "permissions_userId_idx" ON "permissions" ("userId");
+CREATE UNIQUE INDEX IF NOT EXISTS "permissions_grant_idx" ON "permissions" ("userId", "role", COALESCE("teamId", 0)) WHERE "deletedAt" IS NULL;
+
+-- the first admin is granted manually, e.g.
+-- INSERT INTO "permissions" ("userId", "role") VALUES (1, 'admin');